@@ -0,0 +1,167 @@
+package jwt
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// RegisteredClaims contains the registered claims defined by RFC 7519. Embed
+// it in your own struct to use typed claims with Parser.DecodeClaims and
+// Token.SignClaims.
+type RegisteredClaims struct {
+	Issuer    string       `json:"iss,omitempty"`
+	Subject   string       `json:"sub,omitempty"`
+	Audience  Audience     `json:"aud,omitempty"`
+	ExpiresAt *NumericDate `json:"exp,omitempty"`
+	NotBefore *NumericDate `json:"nbf,omitempty"`
+	IssuedAt  *NumericDate `json:"iat,omitempty"`
+	ID        string       `json:"jti,omitempty"`
+}
+
+// NumericDate is a time encoded as the number of seconds since the epoch, as
+// used by the "exp", "nbf" and "iat" claims.
+type NumericDate struct {
+	time.Time
+}
+
+// NewNumericDate creates a new NumericDate from the given time, truncated to
+// whole seconds.
+func NewNumericDate(t time.Time) *NumericDate {
+	return &NumericDate{time.Unix(t.Unix(), 0)}
+}
+
+// MarshalJSON encodes the date as the number of seconds since the epoch.
+func (d NumericDate) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.Unix())
+}
+
+// UnmarshalJSON decodes the date from the number of seconds since the epoch.
+func (d *NumericDate) UnmarshalJSON(b []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+
+	if _, ok := v.(float64); !ok {
+		return ErrInvalidToken
+	}
+	d.Time = time.Unix(int64(v.(float64)), 0)
+
+	return nil
+}
+
+// Audience contains the recipients a token is intended for. As allowed by
+// RFC 7519, it is encoded as a single string when it has exactly one element
+// and as an array of strings otherwise.
+type Audience []string
+
+// Contains checks if the audience contains the given recipient.
+func (a Audience) Contains(aud string) bool {
+	for _, v := range a {
+		if v == aud {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MarshalJSON encodes the audience as a string or an array of strings.
+func (a Audience) MarshalJSON() ([]byte, error) {
+	if len(a) == 1 {
+		return json.Marshal(a[0])
+	}
+
+	return json.Marshal([]string(a))
+}
+
+// UnmarshalJSON decodes the audience from a string or an array of strings.
+func (a *Audience) UnmarshalJSON(b []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+
+	aud, ok := audienceValue(v)
+	if !ok {
+		return ErrInvalidToken
+	}
+
+	*a = aud
+
+	return nil
+}
+
+// audienceValue converts a decoded "aud" claim to an Audience.
+func audienceValue(v interface{}) (Audience, bool) {
+	switch v := v.(type) {
+	case string:
+		return Audience{v}, true
+
+	case []interface{}:
+		aud := make(Audience, 0, len(v))
+		for _, s := range v {
+			if _, ok := s.(string); !ok {
+				return nil, false
+			}
+			aud = append(aud, s.(string))
+		}
+		return aud, true
+	}
+
+	return nil, false
+}
+
+// checkClaims makes sure the claims do not use any reserved claim, except
+// through an embedded RegisteredClaims.
+func checkClaims(v reflect.Value) error {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Map:
+		for _, k := range v.MapKeys() {
+			if k.Kind() != reflect.String {
+				continue
+			}
+			if _, ok := reservedClaims[k.String()]; ok {
+				return ErrReservedClaim
+			}
+		}
+
+	case reflect.Struct:
+		if v.Type() == reflect.TypeOf(RegisteredClaims{}) {
+			return nil
+		}
+
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Type().Field(i)
+			name := strings.Split(f.Tag.Get("json"), ",")[0]
+
+			if f.Anonymous && len(name) == 0 {
+				if err := checkClaims(v.Field(i)); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if len(f.PkgPath) > 0 || name == "-" {
+				continue
+			}
+			if len(name) == 0 {
+				name = f.Name
+			}
+			if _, ok := reservedClaims[name]; ok {
+				return ErrReservedClaim
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,134 @@
+package jwt
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAudienceContains(t *testing.T) {
+	aud := Audience{"MyAudience", "MyOtherAudience"}
+	if !aud.Contains("MyOtherAudience") {
+		t.Fatal("expected true, got false")
+	}
+	if aud.Contains("TestAudience") {
+		t.Fatal("expected false, got true")
+	}
+}
+
+func TestAudienceMarshalJSON_Single(t *testing.T) {
+	b, err := json.Marshal(Audience{"MyAudience"})
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+	if string(b) != `"MyAudience"` {
+		t.Fatalf("expected %#q, got %#q", `"MyAudience"`, b)
+	}
+}
+
+func TestAudienceMarshalJSON_Multiple(t *testing.T) {
+	b, err := json.Marshal(Audience{"MyAudience", "MyOtherAudience"})
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+	if string(b) != `["MyAudience","MyOtherAudience"]` {
+		t.Fatalf("expected %#q, got %#q", `["MyAudience","MyOtherAudience"]`, b)
+	}
+}
+
+func TestAudienceUnmarshalJSON(t *testing.T) {
+	var aud Audience
+	if err := json.Unmarshal([]byte(`"MyAudience"`), &aud); err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+	if len(aud) != 1 || aud[0] != "MyAudience" {
+		t.Fatalf("expected %v, got %v", Audience{"MyAudience"}, aud)
+	}
+	if err := json.Unmarshal([]byte(`["MyAudience","MyOtherAudience"]`), &aud); err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+	if len(aud) != 2 || aud[1] != "MyOtherAudience" {
+		t.Fatalf("expected %v, got %v", Audience{"MyAudience", "MyOtherAudience"}, aud)
+	}
+}
+
+func TestAudienceUnmarshalJSON_Invalid(t *testing.T) {
+	var aud Audience
+	if err := json.Unmarshal([]byte(`["MyAudience",1]`), &aud); err != ErrInvalidToken {
+		t.Fatalf("expected %#q, got %#q", ErrInvalidToken, err)
+	}
+	if err := json.Unmarshal([]byte(`1`), &aud); err != ErrInvalidToken {
+		t.Fatalf("expected %#q, got %#q", ErrInvalidToken, err)
+	}
+}
+
+type testClaims struct {
+	RegisteredClaims
+	Scopes []string `json:"scopes"`
+	Count  int      `json:"count,omitempty"`
+}
+
+func TestNumericDateMarshalJSON(t *testing.T) {
+	b, err := json.Marshal(NewNumericDate(time.Unix(1424776307, 500)))
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+	if string(b) != "1424776307" {
+		t.Fatalf("expected %#q, got %#q", "1424776307", b)
+	}
+}
+
+func TestNumericDateUnmarshalJSON(t *testing.T) {
+	var d NumericDate
+	if err := json.Unmarshal([]byte("1424776307"), &d); err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+	if d.Unix() != 1424776307 {
+		t.Fatalf("expected %d, got %d", 1424776307, d.Unix())
+	}
+}
+
+func TestNumericDateUnmarshalJSON_Invalid(t *testing.T) {
+	var d NumericDate
+	if err := json.Unmarshal([]byte(`"1424776307"`), &d); err != ErrInvalidToken {
+		t.Fatalf("expected %#q, got %#q", ErrInvalidToken, err)
+	}
+}
+
+func TestCheckClaims(t *testing.T) {
+	claims := &testClaims{
+		RegisteredClaims: RegisteredClaims{Issuer: "MyIssuer"},
+	}
+	if err := checkClaims(reflect.ValueOf(claims)); err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+}
+
+func TestCheckClaims_ReservedField(t *testing.T) {
+	claims := struct {
+		Issuer string `json:"iss"`
+	}{"MyIssuer"}
+	if err := checkClaims(reflect.ValueOf(claims)); err != ErrReservedClaim {
+		t.Fatalf("expected %#q, got %#q", ErrReservedClaim, err)
+	}
+}
+
+func TestCheckClaims_ReservedEmbeddedField(t *testing.T) {
+	type embedded struct {
+		ID string `json:"jti"`
+	}
+	claims := struct {
+		embedded
+	}{embedded{"MyID"}}
+	if err := checkClaims(reflect.ValueOf(claims)); err != ErrReservedClaim {
+		t.Fatalf("expected %#q, got %#q", ErrReservedClaim, err)
+	}
+}
+
+func TestCheckClaims_ReservedMapKey(t *testing.T) {
+	claims := map[string]interface{}{"sub": "MySubject"}
+	if err := checkClaims(reflect.ValueOf(claims)); err != ErrReservedClaim {
+		t.Fatalf("expected %#q, got %#q", ErrReservedClaim, err)
+	}
+}
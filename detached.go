@@ -0,0 +1,168 @@
+package jwt
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// SignDetached signs the given payload and returns a token with a detached
+// payload, as described in RFC 7515 appendix F. The token has the form
+// "header..signature" and the payload must be sent along with it, such as in
+// the body of an HTTP request.
+//
+// Only the header fields of the token are used.
+func (t Token) SignDetached(payload []byte, secret interface{}) (string, error) {
+	return t.signDetached(payload, false, secret)
+}
+
+// SignDetachedUnencoded works like SignDetached, but signs the payload as is
+// instead of its base64 encoding, as described in RFC 7797. The header of the
+// token contains "b64": false, which is listed in "crit".
+func (t Token) SignDetachedUnencoded(payload []byte, secret interface{}) (string, error) {
+	return t.signDetached(payload, true, secret)
+}
+
+// signDetached signs the payload and returns the token without it.
+func (t Token) signDetached(payload []byte, unencoded bool, secret interface{}) (string, error) {
+	if err := t.setThumbprintKeyID(secret); err != nil {
+		return "", err
+	}
+
+	header, err := t.buildHeader()
+	if err != nil {
+		return "", err
+	}
+
+	if unencoded {
+		header["b64"] = false
+		header["crit"] = appendCritical(header["crit"], "b64")
+	}
+
+	b, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	h := base64.RawURLEncoding.EncodeToString(b)
+
+	signature, err := signInput(t.Algorithm, signingInput(h, payload, unencoded), secret)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s..%s", h, signature), nil
+}
+
+// DecodeDetached attempts to decode and verify a token with a detached
+// payload, which is supplied separately. Both base64 encoded payloads and
+// unencoded payloads as described in RFC 7797 are supported.
+//
+// The payload is not decoded as claims, so only the header fields of the
+// returned token are set and the Validator of the parser is not used.
+func (p *Parser) DecodeDetached(token string, payload []byte) (*Token, error) {
+	s := strings.Split(token, ".")
+	if len(s) != 3 || len(s[1]) > 0 {
+		return nil, newValidationError(KindMalformed, ErrInvalidToken)
+	}
+
+	t := NewToken()
+
+	header, err := decodeHeader(t, s[0])
+	if err != nil {
+		return nil, newValidationError(KindMalformed, err)
+	}
+
+	if err := p.checkType(header); err != nil {
+		return nil, newValidationError(KindMalformed, err)
+	}
+
+	unencoded, err := unencodedPayload(header)
+	if err != nil {
+		return nil, newValidationError(KindMalformed, err)
+	}
+
+	if err := p.verify(header, signingInput(s[0], payload, unencoded), s[2]); err != nil {
+		return nil, newValidationError(KindSignature, err)
+	}
+
+	if err := p.checkCritical(header); err != nil {
+		return nil, newValidationError(KindMalformed, err)
+	}
+
+	return t, nil
+}
+
+// signingInput returns the JWS signing input for the encoded header and the
+// payload.
+func signingInput(header string, payload []byte, unencoded bool) string {
+	if unencoded {
+		return fmt.Sprintf("%s.%s", header, payload)
+	}
+
+	return fmt.Sprintf("%s.%s", header, base64.RawURLEncoding.EncodeToString(payload))
+}
+
+// encodedPayload makes sure the payload of the token is base64 encoded, as
+// tokens with an unencoded payload can only be decoded using DecodeDetached.
+func encodedPayload(header Header) error {
+	unencoded, err := unencodedPayload(header)
+	if err != nil {
+		return err
+	}
+
+	if unencoded {
+		return ErrUnencodedPayload
+	}
+
+	return nil
+}
+
+// unencodedPayload checks if the header has the "b64" parameter from RFC 7797
+// set to false. As required by RFC 7797, "b64" must be listed in "crit".
+func unencodedPayload(header Header) (bool, error) {
+	v, ok := header["b64"]
+	if !ok {
+		return false, nil
+	}
+
+	if _, ok := v.(bool); !ok {
+		return false, ErrInvalidToken
+	}
+
+	crit, err := header.Critical()
+	if err != nil {
+		return false, err
+	}
+
+	for _, name := range crit {
+		if name == "b64" {
+			return !v.(bool), nil
+		}
+	}
+
+	return false, ErrInvalidToken
+}
+
+// appendCritical adds the name to the value of a "crit" header parameter,
+// unless it is already listed.
+func appendCritical(v interface{}, name string) []interface{} {
+	var crit []interface{}
+	switch v := v.(type) {
+	case []string:
+		for _, s := range v {
+			crit = append(crit, s)
+		}
+
+	case []interface{}:
+		crit = append(crit, v...)
+	}
+
+	for _, s := range crit {
+		if s == name {
+			return crit
+		}
+	}
+
+	return append(crit, name)
+}
@@ -0,0 +1,136 @@
+package jwt
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"gopkg.in/zhevron/jwt.v1/hmac"
+)
+
+// KeyRFC7797 is the HMAC key used by the examples in RFC 7797 section 4.
+var KeyRFC7797, _ = base64.RawURLEncoding.DecodeString("AyM1SysPpbyDfgZld3umj1qzKObwVMkoqQ-EstJQLr_T-1qS0gZH75aKtMN3Yj0iPS4hcgUuTwjAzZr1Z9CAow")
+
+var PayloadRFC7797 = []byte("$.02")
+
+func TestParserDecodeDetached_RFC7797(t *testing.T) {
+	str := "eyJhbGciOiJIUzI1NiJ9..5mvfOroL-g7HyqJoozehmsaqmvTYGEq5jTI1gVvoEoQ"
+	tkn, err := NewParser(staticKey(HS256, KeyRFC7797)).DecodeDetached(str, PayloadRFC7797)
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+	if tkn.Algorithm != HS256 {
+		t.Fatalf("expected %#q, got %#q", HS256, tkn.Algorithm)
+	}
+}
+
+func TestParserDecodeDetached_UnencodedRFC7797(t *testing.T) {
+	str := "eyJhbGciOiJIUzI1NiIsImI2NCI6ZmFsc2UsImNyaXQiOlsiYjY0Il19..A5dxf2s96_n5FLueVuW1Z_vh161FwXZC4YLPff6dmDY"
+	if _, err := NewParser(staticKey(HS256, KeyRFC7797)).DecodeDetached(str, PayloadRFC7797); err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+}
+
+func TestTokenSignDetached(t *testing.T) {
+	tkn := NewToken()
+	tkn.Type = ""
+	str, err := tkn.SignDetached(PayloadRFC7797, KeyRFC7797)
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+	expected := "eyJhbGciOiJIUzI1NiJ9..5mvfOroL-g7HyqJoozehmsaqmvTYGEq5jTI1gVvoEoQ"
+	if str != expected {
+		t.Fatalf("expected %#q, got %#q", expected, str)
+	}
+}
+
+func TestTokenSignDetachedUnencoded(t *testing.T) {
+	tkn := NewToken()
+	str, err := tkn.SignDetachedUnencoded([]byte(`{"amount":"1.00"}`), "secret")
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+
+	p := NewParser(staticKey(HS256, "secret"))
+	if _, err := p.DecodeDetached(str, []byte(`{"amount":"1.00"}`)); err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+	if _, err := p.DecodeDetached(str, []byte(`{"amount":"9.00"}`)); !errors.Is(err, hmac.ErrVerifyFailed) {
+		t.Fatalf("expected %#q, got %#q", hmac.ErrVerifyFailed, err)
+	}
+}
+
+func TestParserDecodeDetached_AttachedPayload(t *testing.T) {
+	str := "eyJhbGciOiJIUzI1NiJ9.JC4wMg.5mvfOroL-g7HyqJoozehmsaqmvTYGEq5jTI1gVvoEoQ"
+	_, err := NewParser(staticKey(HS256, KeyRFC7797)).DecodeDetached(str, PayloadRFC7797)
+	if !errors.Is(err, ErrTokenMalformed) {
+		t.Fatalf("expected %#q, got %#q", ErrTokenMalformed, err)
+	}
+}
+
+func TestParserDecodeDetached_NotCritical(t *testing.T) {
+	// {"alg":"HS256","b64":false}
+	str := "eyJhbGciOiJIUzI1NiIsImI2NCI6ZmFsc2V9..A5dxf2s96_n5FLueVuW1Z_vh161FwXZC4YLPff6dmDY"
+	_, err := NewParser(staticKey(HS256, KeyRFC7797)).DecodeDetached(str, PayloadRFC7797)
+	if !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("expected %#q, got %#q", ErrInvalidToken, err)
+	}
+}
+
+func TestParserDecode_UnencodedPayload(t *testing.T) {
+	str := "eyJhbGciOiJIUzI1NiIsImI2NCI6ZmFsc2UsImNyaXQiOlsiYjY0Il19.JC4wMg.A5dxf2s96_n5FLueVuW1Z_vh161FwXZC4YLPff6dmDY"
+	_, err := NewParser(staticKey(HS256, KeyRFC7797)).Decode(str)
+	if !errors.Is(err, ErrUnencodedPayload) {
+		t.Fatalf("expected %#q, got %#q", ErrUnencodedPayload, err)
+	}
+}
+
+func TestHeaderCritical(t *testing.T) {
+	crit, err := Header{"crit": []interface{}{"b64", "exp"}}.Critical()
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+	if len(crit) != 2 || crit[1] != "exp" {
+		t.Fatalf("expected %v, got %v", []string{"b64", "exp"}, crit)
+	}
+	for _, v := range []interface{}{"b64", []interface{}{}, []interface{}{1}} {
+		if _, err := (Header{"crit": v}).Critical(); err != ErrInvalidToken {
+			t.Fatalf("expected %#q, got %#q", ErrInvalidToken, err)
+		}
+	}
+}
+
+func TestAppendCritical(t *testing.T) {
+	crit := appendCritical([]string{"exp"}, "b64")
+	if len(crit) != 2 || crit[0] != "exp" || crit[1] != "b64" {
+		t.Fatalf("expected %v, got %v", []string{"exp", "b64"}, crit)
+	}
+	crit = appendCritical([]interface{}{"b64"}, "b64")
+	if len(crit) != 1 {
+		t.Fatalf("expected %v, got %v", []string{"b64"}, crit)
+	}
+	crit = appendCritical(nil, "b64")
+	if len(crit) != 1 || crit[0] != "b64" {
+		t.Fatalf("expected %v, got %v", []string{"b64"}, crit)
+	}
+}
+
+func TestTokenSignDetachedUnencoded_Critical(t *testing.T) {
+	tkn := NewToken()
+	tkn.Headers["crit"] = []string{"exp"}
+	tkn.Headers["exp"] = 1363284000
+	str, err := tkn.SignDetachedUnencoded([]byte("$.02"), "secret")
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+
+	p := NewParser(staticKey(HS256, "secret"))
+	if _, err := p.DecodeDetached(str, []byte("$.02")); !errors.Is(err, ErrUnsupportedCritical) {
+		t.Fatalf("expected %#q, got %#q", ErrUnsupportedCritical, err)
+	}
+
+	p.Critical = map[string]CriticalHandler{"exp": nil}
+	if _, err := p.DecodeDetached(str, []byte("$.02")); err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+}
@@ -4,12 +4,15 @@ package ecdsa
 import (
 	"crypto"
 	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/x509"
 	"encoding/base64"
 	"encoding/pem"
 	"errors"
 	"math/big"
+
+	"gopkg.in/zhevron/jwt.v1/jwk"
 )
 
 var (
@@ -19,6 +22,10 @@ var (
 	// ErrInvalidKey is returned when the given key is not a valid ECDSA key.
 	ErrInvalidKey = errors.New("jwt/ecdsa: invalid key")
 
+	// ErrInvalidCurve is returned when the curve of the key does not match the
+	// curve required by the algorithm.
+	ErrInvalidCurve = errors.New("jwt/ecdsa: invalid curve")
+
 	// ErrInvalidSignature is returned when the signature provided cannot be decoded.
 	ErrInvalidSignature = errors.New("jwt/ecdsa: invalid signature")
 
@@ -33,7 +40,7 @@ func SignES256(token string, secret interface{}) (string, error) {
 		return "", err
 	}
 
-	return computeHash(token, crypto.SHA256, k)
+	return computeHash(token, crypto.SHA256, elliptic.P256(), k)
 }
 
 // SignES384 signs a token with the provided secret using ECDSA SHA-384.
@@ -43,7 +50,7 @@ func SignES384(token string, secret interface{}) (string, error) {
 		return "", err
 	}
 
-	return computeHash(token, crypto.SHA384, k)
+	return computeHash(token, crypto.SHA384, elliptic.P384(), k)
 }
 
 // SignES512 signs a token with the provided secret using ECDSA SHA-512.
@@ -53,7 +60,7 @@ func SignES512(token string, secret interface{}) (string, error) {
 		return "", err
 	}
 
-	return computeHash(token, crypto.SHA512, k)
+	return computeHash(token, crypto.SHA512, elliptic.P521(), k)
 }
 
 // VerifyES256 verifies the given signature using the given secret.
@@ -63,7 +70,7 @@ func VerifyES256(token, signature string, secret interface{}) error {
 		return err
 	}
 
-	return verifySignature(token, signature, crypto.SHA256, k)
+	return verifySignature(token, signature, crypto.SHA256, elliptic.P256(), k)
 }
 
 // VerifyES384 verifies the given signature using the given secret.
@@ -73,7 +80,7 @@ func VerifyES384(token, signature string, secret interface{}) error {
 		return err
 	}
 
-	return verifySignature(token, signature, crypto.SHA384, k)
+	return verifySignature(token, signature, crypto.SHA384, elliptic.P384(), k)
 }
 
 // VerifyES512 verifies the given signature using the given secret.
@@ -83,11 +90,17 @@ func VerifyES512(token, signature string, secret interface{}) error {
 		return err
 	}
 
-	return verifySignature(token, signature, crypto.SHA512, k)
+	return verifySignature(token, signature, crypto.SHA512, elliptic.P521(), k)
 }
 
 // computeHash calculates the hash for a token using the provided algorithm.
-func computeHash(tkn string, h crypto.Hash, k *ecdsa.PrivateKey) (string, error) {
+// The signature is encoded as the fixed-width concatenation of R and S as
+// described in RFC 7518 section 3.4.
+func computeHash(tkn string, h crypto.Hash, c elliptic.Curve, k *ecdsa.PrivateKey) (string, error) {
+	if !checkCurve(c, &k.PublicKey) {
+		return "", ErrInvalidCurve
+	}
+
 	hash := h.New()
 	hash.Write([]byte(tkn))
 
@@ -96,31 +109,37 @@ func computeHash(tkn string, h crypto.Hash, k *ecdsa.PrivateKey) (string, error)
 		return "", err
 	}
 
-	var b []byte
-	b = append(b, r.Bytes()...)
-	b = append(b, s.Bytes()...)
+	size := keySize(c)
+	b := make([]byte, 2*size)
+	r.FillBytes(b[:size])
+	s.FillBytes(b[size:])
 
-	return base64.URLEncoding.EncodeToString(b), nil
+	return base64.RawURLEncoding.EncodeToString(b), nil
 }
 
 // verifySignature verifies the signature using the given algorithm.
-func verifySignature(tkn, sig string, h crypto.Hash, k *ecdsa.PublicKey) error {
+func verifySignature(tkn, sig string, h crypto.Hash, c elliptic.Curve, k *ecdsa.PublicKey) error {
+	if !checkCurve(c, k) {
+		return ErrInvalidCurve
+	}
+
 	hash := h.New()
 	hash.Write([]byte(tkn))
 
-	b, err := base64.URLEncoding.DecodeString(sig)
+	b, err := base64.RawURLEncoding.DecodeString(sig)
 	if err != nil {
-		return err
+		return ErrInvalidSignature
 	}
 
-	if len(b) != 64 {
+	size := keySize(c)
+	if len(b) != 2*size {
 		return ErrInvalidSignature
 	}
 
 	r := new(big.Int)
-	r.SetBytes(b[:32])
+	r.SetBytes(b[:size])
 	s := new(big.Int)
-	s.SetBytes(b[32:])
+	s.SetBytes(b[size:])
 
 	if !ecdsa.Verify(k, hash.Sum(nil), r, s) {
 		return ErrVerifyFailed
@@ -129,12 +148,38 @@ func verifySignature(tkn, sig string, h crypto.Hash, k *ecdsa.PublicKey) error {
 	return nil
 }
 
+// checkCurve checks that the key uses the curve required by the algorithm.
+func checkCurve(c elliptic.Curve, k *ecdsa.PublicKey) bool {
+	return k.Curve != nil && k.Curve.Params().Name == c.Params().Name
+}
+
+// keySize returns the number of bytes used to encode R and S for the curve.
+func keySize(c elliptic.Curve) int {
+	return (c.Params().BitSize + 7) / 8
+}
+
+// ParsePrivateKey returns the ECDSA private key from the secret. It accepts
+// the same types as SignES256, which makes it possible for other packages to
+// reuse the key parsing.
+func ParsePrivateKey(key interface{}) (*ecdsa.PrivateKey, error) {
+	return privateKey(key)
+}
+
+// ParsePublicKey returns the ECDSA public key from the secret. It accepts the
+// same types as VerifyES256.
+func ParsePublicKey(key interface{}) (*ecdsa.PublicKey, error) {
+	return publicKey(key)
+}
+
 // privateKey returns the ECDSA private key from the secret.
 func privateKey(key interface{}) (*ecdsa.PrivateKey, error) {
 	switch key.(type) {
 	case *ecdsa.PrivateKey:
 		return key.(*ecdsa.PrivateKey), nil
 
+	case *jwk.Key:
+		return privateKey(key.(*jwk.Key).Key)
+
 	case string:
 		return privateKey([]byte(key.(string)))
 
@@ -167,6 +212,9 @@ func publicKey(key interface{}) (*ecdsa.PublicKey, error) {
 	case *ecdsa.PublicKey:
 		return key.(*ecdsa.PublicKey), nil
 
+	case *jwk.Key:
+		return publicKey(key.(*jwk.Key).Public().Key)
+
 	case string:
 		return publicKey([]byte(key.(string)))
 
@@ -0,0 +1,162 @@
+// Package eddsa provides Edwards-curve signing methods for JWT.
+package eddsa
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+
+	"gopkg.in/zhevron/jwt.v1/jwk"
+)
+
+var (
+	// ErrVerifyFailed is returned when signature verification fails.
+	ErrVerifyFailed = errors.New("jwt/eddsa: verification failed")
+
+	// ErrInvalidSignature is returned when the signature is not a valid encoding.
+	ErrInvalidSignature = errors.New("jwt/eddsa: invalid signature")
+
+	// ErrInvalidKey is returned when the given key is not a valid Ed25519 key.
+	ErrInvalidKey = errors.New("jwt/eddsa: invalid key")
+
+	// ErrUnsupportedKeyType is returned when the secret is not a supported type.
+	ErrUnsupportedKeyType = errors.New("jwt/eddsa: unsupported key type")
+)
+
+// SignEdDSA signs a token with the provided secret using Ed25519.
+func SignEdDSA(token string, secret interface{}) (string, error) {
+	k, err := privateKey(secret)
+	if err != nil {
+		return "", err
+	}
+
+	return computeHash(token, k)
+}
+
+// VerifyEdDSA verifies the given signature using the given secret.
+func VerifyEdDSA(token, signature string, secret interface{}) error {
+	k, err := publicKey(secret)
+	if err != nil {
+		return err
+	}
+
+	return verifySignature(token, signature, k)
+}
+
+// computeHash calculates the signature for a token. Ed25519 hashes the
+// message internally, so no separate digest is computed here.
+func computeHash(tkn string, k ed25519.PrivateKey) (string, error) {
+	b := ed25519.Sign(k, []byte(tkn))
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// verifySignature verifies the signature for a token.
+func verifySignature(tkn, sig string, k ed25519.PublicKey) error {
+	b, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	if !ed25519.Verify(k, []byte(tkn), b) {
+		return ErrVerifyFailed
+	}
+
+	return nil
+}
+
+// ParsePrivateKey returns the Ed25519 private key from the secret. It accepts
+// the same types as SignEdDSA, which makes it possible for other packages to
+// reuse the key parsing.
+func ParsePrivateKey(key interface{}) (ed25519.PrivateKey, error) {
+	return privateKey(key)
+}
+
+// ParsePublicKey returns the Ed25519 public key from the secret. It accepts
+// the same types as VerifyEdDSA.
+func ParsePublicKey(key interface{}) (ed25519.PublicKey, error) {
+	return publicKey(key)
+}
+
+// privateKey returns the Ed25519 private key from the secret.
+func privateKey(key interface{}) (ed25519.PrivateKey, error) {
+	switch key.(type) {
+	case ed25519.PrivateKey:
+		if len(key.(ed25519.PrivateKey)) != ed25519.PrivateKeySize {
+			return nil, ErrInvalidKey
+		}
+		return key.(ed25519.PrivateKey), nil
+
+	case *ed25519.PrivateKey:
+		return privateKey(*key.(*ed25519.PrivateKey))
+
+	case *jwk.Key:
+		return privateKey(key.(*jwk.Key).Key)
+
+	case string:
+		return privateKey([]byte(key.(string)))
+
+	case []byte:
+		b, _ := pem.Decode(key.([]byte))
+		if b == nil {
+			return nil, ErrInvalidKey
+		}
+
+		k, err := x509.ParsePKCS8PrivateKey(b.Bytes)
+		if err != nil {
+			return nil, err
+		}
+
+		if k, ok := k.(ed25519.PrivateKey); ok {
+			return k, nil
+		}
+		return nil, ErrInvalidKey
+	}
+
+	return nil, ErrUnsupportedKeyType
+}
+
+// publicKey returns the Ed25519 public key from the secret.
+func publicKey(key interface{}) (ed25519.PublicKey, error) {
+	switch key.(type) {
+	case ed25519.PublicKey:
+		if len(key.(ed25519.PublicKey)) != ed25519.PublicKeySize {
+			return nil, ErrInvalidKey
+		}
+		return key.(ed25519.PublicKey), nil
+
+	case *ed25519.PublicKey:
+		return publicKey(*key.(*ed25519.PublicKey))
+
+	case *jwk.Key:
+		return publicKey(key.(*jwk.Key).Public().Key)
+
+	case string:
+		return publicKey([]byte(key.(string)))
+
+	case []byte:
+		b, _ := pem.Decode(key.([]byte))
+		if b == nil {
+			return nil, ErrInvalidKey
+		}
+
+		var k interface{}
+		k, err := x509.ParsePKIXPublicKey(b.Bytes)
+		if err != nil {
+			if c, err := x509.ParseCertificate(b.Bytes); err == nil {
+				k = c.PublicKey
+			} else {
+				return nil, ErrInvalidKey
+			}
+		}
+
+		if k, ok := k.(ed25519.PublicKey); ok {
+			return k, nil
+		}
+		return nil, ErrInvalidKey
+	}
+
+	return nil, ErrUnsupportedKeyType
+}
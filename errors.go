@@ -0,0 +1,114 @@
+package jwt
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrorKind is used to classify why a token was rejected.
+type ErrorKind int
+
+const (
+	// KindMalformed means the token could not be decoded.
+	KindMalformed ErrorKind = iota + 1
+
+	// KindSignature means the signature of the token could not be verified.
+	KindSignature
+
+	// KindClaims means one or more claims of the token are invalid.
+	KindClaims
+
+	// KindDecryption means an encrypted token could not be decrypted.
+	KindDecryption
+)
+
+var (
+	// ErrTokenMalformed matches any ValidationError of kind KindMalformed.
+	ErrTokenMalformed = errors.New("jwt: token malformed")
+
+	// ErrTokenSignatureInvalid matches any ValidationError of kind KindSignature.
+	ErrTokenSignatureInvalid = errors.New("jwt: token signature invalid")
+
+	// ErrTokenClaimsInvalid matches any ValidationError of kind KindClaims.
+	ErrTokenClaimsInvalid = errors.New("jwt: token claims invalid")
+
+	// ErrTokenDecryptionFailed matches any ValidationError of kind KindDecryption.
+	ErrTokenDecryptionFailed = errors.New("jwt: token decryption failed")
+)
+
+// ValidationError is returned when a token cannot be decoded, verified or
+// validated. It contains every check that failed, so errors.Is can be used to
+// look for a specific error such as ErrTokenExpired.
+type ValidationError struct {
+	// Kind is the reason the token was rejected.
+	Kind ErrorKind
+
+	// Errors contains the errors of every failed check.
+	Errors []error
+}
+
+// newValidationError creates a new ValidationError of the given kind.
+func newValidationError(kind ErrorKind, errs ...error) *ValidationError {
+	return &ValidationError{
+		Kind:   kind,
+		Errors: errs,
+	}
+}
+
+// Error returns the messages of all the errors.
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		msgs = append(msgs, err.Error())
+	}
+
+	return strings.Join(msgs, "; ")
+}
+
+// Unwrap returns the errors of every failed check.
+func (e *ValidationError) Unwrap() []error {
+	return e.Errors
+}
+
+// Is checks if the target is the sentinel error for the kind of the error.
+func (e *ValidationError) Is(target error) bool {
+	switch target {
+	case ErrTokenMalformed:
+		return e.Kind == KindMalformed
+
+	case ErrTokenSignatureInvalid:
+		return e.Kind == KindSignature
+
+	case ErrTokenClaimsInvalid:
+		return e.Kind == KindClaims
+
+	case ErrTokenDecryptionFailed:
+		return e.Kind == KindDecryption
+	}
+
+	return false
+}
+
+// ClaimError is used by ValidationError to record a failed claim check along
+// with the offending claim value.
+type ClaimError struct {
+	// Claim is the name of the claim, such as "exp".
+	Claim string
+
+	// Value is the value of the claim in the token.
+	Value interface{}
+
+	// Err is the reason the check failed, such as ErrTokenExpired.
+	Err error
+}
+
+// Error returns the reason along with the claim and its value.
+func (e *ClaimError) Error() string {
+	return fmt.Sprintf("%v (%s: %v)", e.Err, e.Claim, e.Value)
+}
+
+// Unwrap returns the reason the check failed.
+func (e *ClaimError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,48 @@
+package jwt
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidationErrorIs(t *testing.T) {
+	err := error(newValidationError(KindClaims, &ClaimError{"exp", 0, ErrTokenExpired}, &ClaimError{"iss", "TestIssuer", ErrInvalidIssuer}))
+	if !errors.Is(err, ErrTokenClaimsInvalid) {
+		t.Fatalf("expected %#q, got %#q", ErrTokenClaimsInvalid, err)
+	}
+	if errors.Is(err, ErrTokenMalformed) || errors.Is(err, ErrTokenSignatureInvalid) {
+		t.Fatalf("expected only %#q, got %#q", ErrTokenClaimsInvalid, err)
+	}
+	if !errors.Is(err, ErrTokenExpired) {
+		t.Fatalf("expected %#q, got %#q", ErrTokenExpired, err)
+	}
+	if !errors.Is(err, ErrInvalidIssuer) {
+		t.Fatalf("expected %#q, got %#q", ErrInvalidIssuer, err)
+	}
+}
+
+func TestValidationErrorAs(t *testing.T) {
+	err := error(newValidationError(KindClaims, &ClaimError{"iss", "TestIssuer", ErrInvalidIssuer}))
+	var claimErr *ClaimError
+	if !errors.As(err, &claimErr) {
+		t.Fatal("expected true, got false")
+	}
+	if claimErr.Claim != "iss" || claimErr.Value != "TestIssuer" {
+		t.Fatalf("expected %#q, got %#q", "iss", claimErr.Claim)
+	}
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatal("expected true, got false")
+	}
+	if validationErr.Kind != KindClaims {
+		t.Fatalf("expected %d, got %d", KindClaims, validationErr.Kind)
+	}
+}
+
+func TestValidationErrorError(t *testing.T) {
+	err := newValidationError(KindClaims, &ClaimError{"iss", "TestIssuer", ErrInvalidIssuer}, &ClaimError{"sub", "TestSubject", ErrInvalidSubject})
+	expected := "jwt: invalid issuer (iss: TestIssuer); jwt: invalid subject (sub: TestSubject)"
+	if err.Error() != expected {
+		t.Fatalf("expected %#q, got %#q", expected, err.Error())
+	}
+}
@@ -2,91 +2,150 @@
 package hmac
 
 import (
+	"bytes"
 	"crypto/hmac"
 	"crypto/sha256"
 	"crypto/sha512"
 	"encoding/base64"
 	"errors"
 	"hash"
+
+	"gopkg.in/zhevron/jwt.v1/jwk"
 )
 
 var (
 	// ErrVerifyFailed is returned when signature verification fails.
 	ErrVerifyFailed = errors.New("jwt/hmac: verification failed")
 
+	// ErrInvalidSignature is returned when the signature is not a valid encoding.
+	ErrInvalidSignature = errors.New("jwt/hmac: invalid signature")
+
+	// ErrInvalidKey is returned when the secret is a PEM encoded key, which
+	// indicates an asymmetric key being used as an HMAC secret.
+	ErrInvalidKey = errors.New("jwt/hmac: invalid key")
+
+	// ErrKeyTooShort is returned when a MinKeyLength key is shorter than the
+	// hash output.
+	ErrKeyTooShort = errors.New("jwt/hmac: key too short")
+
 	// ErrUnsupportedKeyType is returned when the secret is not a supported type.
 	ErrUnsupportedKeyType = errors.New("jwt/hmac: unsupported key type")
 )
 
+// MinKeyLength wraps a secret to require it to be at least as long as the
+// hash output, as required by RFC 7518 section 3.2. That is 32 bytes for
+// HS256, 48 bytes for HS384 and 64 bytes for HS512. Signing and verifying with
+// a shorter key fails with ErrKeyTooShort.
+//
+// The requirement only applies where the wrapped secret is used, so each
+// caller can choose its own policy.
+type MinKeyLength struct {
+	Key interface{}
+}
+
 // SignHS256 signs a token with the provided secret using HMAC SHA-256.
 func SignHS256(token string, secret interface{}) (string, error) {
-	s, err := checkSecret(secret)
-	if err != nil {
-		return "", err
-	}
-
-	return computeHash(token, hmac.New(sha256.New, s))
+	return sign(token, sha256.New, secret)
 }
 
 // SignHS384 signs a token with the provided secret using HMAC SHA-384.
 func SignHS384(token string, secret interface{}) (string, error) {
-	s, err := checkSecret(secret)
-	if err != nil {
-		return "", err
-	}
-
-	return computeHash(token, hmac.New(sha512.New384, s))
+	return sign(token, sha512.New384, secret)
 }
 
 // SignHS512 signs a token with the provided secret using HMAC SHA-512.
 func SignHS512(token string, secret interface{}) (string, error) {
-	s, err := checkSecret(secret)
-	if err != nil {
-		return "", err
-	}
-
-	return computeHash(token, hmac.New(sha512.New, s))
+	return sign(token, sha512.New, secret)
 }
 
 // VerifyHS256 verifies the given signature using the given secret.
 func VerifyHS256(token, signature string, secret interface{}) error {
-	if s, _ := SignHS256(token, secret); s != signature {
-		return ErrVerifyFailed
-	}
-	return nil
+	return verify(token, signature, sha256.New, secret)
 }
 
 // VerifyHS384 verifies the given signature using the given secret.
 func VerifyHS384(token, signature string, secret interface{}) error {
-	if s, _ := SignHS384(token, secret); s != signature {
-		return ErrVerifyFailed
-	}
-	return nil
+	return verify(token, signature, sha512.New384, secret)
 }
 
 // VerifyHS512 verifies the given signature using the given secret.
 func VerifyHS512(token, signature string, secret interface{}) error {
-	if s, _ := SignHS512(token, secret); s != signature {
+	return verify(token, signature, sha512.New, secret)
+}
+
+// sign calculates the base64 encoded MAC for a token.
+func sign(token string, h func() hash.Hash, secret interface{}) (string, error) {
+	mac, err := computeHash(token, h, secret)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(mac), nil
+}
+
+// verify compares the MAC of a token with the signature in constant time.
+func verify(token, signature string, h func() hash.Hash, secret interface{}) error {
+	mac, err := computeHash(token, h, secret)
+	if err != nil {
+		return err
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	if !hmac.Equal(mac, b) {
 		return ErrVerifyFailed
 	}
+
 	return nil
 }
 
-// computeHash calculates the hash for a token using the provided algorithm.
-func computeHash(token string, h hash.Hash) (string, error) {
-	h.Write([]byte(token))
-	return base64.URLEncoding.EncodeToString(h.Sum(nil)), nil
+// computeHash calculates the MAC for a token using the provided algorithm.
+func computeHash(token string, h func() hash.Hash, secret interface{}) ([]byte, error) {
+	k, strict := secret.(MinKeyLength)
+	if strict {
+		secret = k.Key
+	}
+
+	s, err := checkSecret(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	mac := hmac.New(h, s)
+	if strict && len(s) < mac.Size() {
+		return nil, ErrKeyTooShort
+	}
+
+	mac.Write([]byte(token))
+
+	return mac.Sum(nil), nil
 }
 
 // checkSecret checks that the provided secret is a valid type.
 func checkSecret(secret interface{}) ([]byte, error) {
 	switch secret.(type) {
+	case *jwk.Key:
+		return checkSecret(secret.(*jwk.Key).Key)
+
 	case []byte:
-		return secret.([]byte), nil
+		return checkPEM(secret.([]byte))
 
 	case string:
-		return []byte(secret.(string)), nil
+		return checkPEM([]byte(secret.(string)))
 	}
 
 	return nil, ErrUnsupportedKeyType
 }
+
+// checkPEM makes sure the secret is not a PEM encoded key, such as an RSA
+// public key passed as the secret for an HS256 token.
+func checkPEM(secret []byte) ([]byte, error) {
+	if bytes.Contains(secret, []byte("-----BEGIN ")) {
+		return nil, ErrInvalidKey
+	}
+
+	return secret, nil
+}
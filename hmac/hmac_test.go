@@ -1,13 +1,17 @@
 package hmac
 
-import "testing"
+import (
+	"testing"
+
+	"gopkg.in/zhevron/jwt.v1/jwk"
+)
 
 var Secret = "secret"
 var Token = "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.eyJpYXQiOjE0MjQ3NzYzMDcsImlzcyI6Ik15SXNzdWVyIiwic2NvcGVzIjpbIm15X3Njb3BlIl19"
 
-var SignatureHS256 = "cMrSIdfeoGxOtgoZcNufWR2DGFP-qncUOdfrGCPJLZY="
+var SignatureHS256 = "cMrSIdfeoGxOtgoZcNufWR2DGFP-qncUOdfrGCPJLZY"
 var SignatureHS384 = "1iCCiZmnb_BxpDevfB1Af10qVNEEoKzhC-K-HrdZEauAKBWCeyT-syWZYv6Pp9zT"
-var SignatureHS512 = "nbv50JDKuBqZ1vmIvCOg4YoZUiiecuZAWCm8Q61PvtyajjuUHTrOGCUtWI6XwYKPN2n-8S_AZRqW2AoREOgLXg=="
+var SignatureHS512 = "nbv50JDKuBqZ1vmIvCOg4YoZUiiecuZAWCm8Q61PvtyajjuUHTrOGCUtWI6XwYKPN2n-8S_AZRqW2AoREOgLXg"
 
 func TestSignHS256(t *testing.T) {
 	str, err := SignHS256(Token, Secret)
@@ -110,3 +114,78 @@ func TestVerifyHS512_Fail(t *testing.T) {
 		t.Fatalf("expected %#q, got %#q", ErrVerifyFailed, err)
 	}
 }
+
+func TestSignHS256_JWK(t *testing.T) {
+	str, err := SignHS256(Token, &jwk.Key{Key: []byte(Secret)})
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+	if str != SignatureHS256 {
+		t.Fatalf("expected %#q, got %#q", SignatureHS256, str)
+	}
+}
+
+func TestSignHS256_JWKUnsupportedKeyType(t *testing.T) {
+	if _, err := SignHS256(Token, &jwk.Key{Key: 0}); err != ErrUnsupportedKeyType {
+		t.Fatalf("expected %#q, got %#q", ErrUnsupportedKeyType, err)
+	}
+}
+
+func TestVerifyHS256_InvalidSignature(t *testing.T) {
+	if err := VerifyHS256(Token, "!", Secret); err != ErrInvalidSignature {
+		t.Fatalf("expected %#q, got %#q", ErrInvalidSignature, err)
+	}
+}
+
+func TestVerifyHS256_UnsupportedKeyType(t *testing.T) {
+	if err := VerifyHS256(Token, SignatureHS256, 0); err != ErrUnsupportedKeyType {
+		t.Fatalf("expected %#q, got %#q", ErrUnsupportedKeyType, err)
+	}
+}
+
+func TestVerifyHS256_Truncated(t *testing.T) {
+	if err := VerifyHS256(Token, SignatureHS256[:10], Secret); err != ErrVerifyFailed {
+		t.Fatalf("expected %#q, got %#q", ErrVerifyFailed, err)
+	}
+}
+
+func TestMinKeyLength(t *testing.T) {
+	if _, err := SignHS256(Token, MinKeyLength{Secret}); err != ErrKeyTooShort {
+		t.Fatalf("expected %#q, got %#q", ErrKeyTooShort, err)
+	}
+	if err := VerifyHS256(Token, SignatureHS256, MinKeyLength{Secret}); err != ErrKeyTooShort {
+		t.Fatalf("expected %#q, got %#q", ErrKeyTooShort, err)
+	}
+
+	key := MinKeyLength{make([]byte, 32)}
+	if _, err := SignHS256(Token, key); err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+	if _, err := SignHS384(Token, key); err != ErrKeyTooShort {
+		t.Fatalf("expected %#q, got %#q", ErrKeyTooShort, err)
+	}
+
+	str, err := SignHS512(Token, MinKeyLength{make([]byte, 64)})
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+	if err := VerifyHS512(Token, str, MinKeyLength{make([]byte, 64)}); err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+}
+
+func TestSignHS256_PEMKey(t *testing.T) {
+	key := "-----BEGIN PUBLIC KEY-----\nMFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAE\n-----END PUBLIC KEY-----"
+	if _, err := SignHS256(Token, key); err != ErrInvalidKey {
+		t.Fatalf("expected %#q, got %#q", ErrInvalidKey, err)
+	}
+	if err := VerifyHS256(Token, SignatureHS256, []byte(key)); err != ErrInvalidKey {
+		t.Fatalf("expected %#q, got %#q", ErrInvalidKey, err)
+	}
+}
+
+func TestMinKeyLength_Unwrapped(t *testing.T) {
+	if _, err := SignHS256(Token, Secret); err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+}
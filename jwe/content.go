@@ -0,0 +1,183 @@
+package jwe
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/binary"
+	"hash"
+)
+
+// contentKeySizes is used to determine the content encryption key size of
+// each content encryption algorithm.
+var contentKeySizes = map[ContentEncryption]int{
+	A128GCM:      16,
+	A256GCM:      32,
+	A128CBCHS256: 32,
+	A256CBCHS512: 64,
+}
+
+// encryptContent encrypts the plaintext using a random initialization vector,
+// and returns the initialization vector, ciphertext and authentication tag.
+func encryptContent(enc ContentEncryption, cek, plaintext, aad []byte) ([]byte, []byte, []byte, error) {
+	switch enc {
+	case A128GCM, A256GCM:
+		return encryptGCM(cek, plaintext, aad)
+
+	case A128CBCHS256:
+		return encryptCBC(cek, plaintext, aad, sha256.New)
+
+	case A256CBCHS512:
+		return encryptCBC(cek, plaintext, aad, sha512.New)
+	}
+
+	return nil, nil, nil, ErrUnsupportedEncryption
+}
+
+// decryptContent authenticates and decrypts the ciphertext.
+func decryptContent(enc ContentEncryption, cek, iv, ciphertext, tag, aad []byte) ([]byte, error) {
+	switch enc {
+	case A128GCM, A256GCM:
+		return decryptGCM(cek, iv, ciphertext, tag, aad)
+
+	case A128CBCHS256:
+		return decryptCBC(cek, iv, ciphertext, tag, aad, sha256.New)
+
+	case A256CBCHS512:
+		return decryptCBC(cek, iv, ciphertext, tag, aad, sha512.New)
+	}
+
+	return nil, ErrUnsupportedEncryption
+}
+
+// encryptGCM encrypts the plaintext using AES GCM.
+func encryptGCM(cek, plaintext, aad []byte) ([]byte, []byte, []byte, error) {
+	aead, err := newGCM(cek)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	iv := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(iv); err != nil {
+		return nil, nil, nil, err
+	}
+
+	b := aead.Seal(nil, iv, plaintext, aad)
+	size := len(b) - aead.Overhead()
+
+	return iv, b[:size], b[size:], nil
+}
+
+// decryptGCM decrypts the ciphertext using AES GCM.
+func decryptGCM(cek, iv, ciphertext, tag, aad []byte) ([]byte, error) {
+	aead, err := newGCM(cek)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(iv) != aead.NonceSize() || len(tag) != aead.Overhead() {
+		return nil, ErrDecryptFailed
+	}
+
+	b := make([]byte, 0, len(ciphertext)+len(tag))
+	b = append(b, ciphertext...)
+	b = append(b, tag...)
+
+	plaintext, err := aead.Open(nil, iv, b, aad)
+	if err != nil {
+		return nil, ErrDecryptFailed
+	}
+
+	return plaintext, nil
+}
+
+// newGCM creates the AES GCM cipher for the content encryption key.
+func newGCM(cek []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, ErrInvalidKey
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// encryptCBC encrypts the plaintext using AES CBC with HMAC, as described in
+// RFC 7518 section 5.2. The first half of the key is used for the MAC and
+// the second half for encryption.
+func encryptCBC(cek, plaintext, aad []byte, h func() hash.Hash) ([]byte, []byte, []byte, error) {
+	macKey, encKey := cek[:len(cek)/2], cek[len(cek)/2:]
+
+	block, err := aes.NewCipher(encKey)
+	if err != nil {
+		return nil, nil, nil, ErrInvalidKey
+	}
+
+	iv := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(iv); err != nil {
+		return nil, nil, nil, err
+	}
+
+	padding := aes.BlockSize - len(plaintext)%aes.BlockSize
+	ciphertext := make([]byte, len(plaintext)+padding)
+	copy(ciphertext, plaintext)
+	for i := len(plaintext); i < len(ciphertext); i++ {
+		ciphertext[i] = byte(padding)
+	}
+
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(ciphertext, ciphertext)
+
+	return iv, ciphertext, computeTag(macKey, iv, ciphertext, aad, h), nil
+}
+
+// decryptCBC authenticates the ciphertext and decrypts it using AES CBC.
+func decryptCBC(cek, iv, ciphertext, tag, aad []byte, h func() hash.Hash) ([]byte, error) {
+	macKey, encKey := cek[:len(cek)/2], cek[len(cek)/2:]
+
+	if !hmac.Equal(tag, computeTag(macKey, iv, ciphertext, aad, h)) {
+		return nil, ErrDecryptFailed
+	}
+
+	block, err := aes.NewCipher(encKey)
+	if err != nil {
+		return nil, ErrInvalidKey
+	}
+
+	if len(iv) != aes.BlockSize || len(ciphertext) == 0 || len(ciphertext)%aes.BlockSize != 0 {
+		return nil, ErrDecryptFailed
+	}
+
+	plaintext := make([]byte, len(ciphertext))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plaintext, ciphertext)
+
+	padding := int(plaintext[len(plaintext)-1])
+	if padding == 0 || padding > aes.BlockSize {
+		return nil, ErrDecryptFailed
+	}
+	for _, b := range plaintext[len(plaintext)-padding:] {
+		if subtle.ConstantTimeByteEq(b, byte(padding)) != 1 {
+			return nil, ErrDecryptFailed
+		}
+	}
+
+	return plaintext[:len(plaintext)-padding], nil
+}
+
+// computeTag calculates the authentication tag over the additional
+// authenticated data, initialization vector, ciphertext and the length of
+// the additional authenticated data in bits.
+func computeTag(macKey, iv, ciphertext, aad []byte, h func() hash.Hash) []byte {
+	al := make([]byte, 8)
+	binary.BigEndian.PutUint64(al, uint64(len(aad))*8)
+
+	mac := hmac.New(h, macKey)
+	mac.Write(aad)
+	mac.Write(iv)
+	mac.Write(ciphertext)
+	mac.Write(al)
+
+	return mac.Sum(nil)[:len(macKey)]
+}
@@ -0,0 +1,243 @@
+// Package jwe provides JSON Web Encryption (RFC 7516) using the compact
+// serialization.
+package jwe
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gopkg.in/zhevron/jwt.v1/jwk"
+)
+
+// KeyAlgorithm is used to define the algorithm used to encrypt or agree upon
+// the content encryption key.
+type KeyAlgorithm string
+
+const (
+	// RSAOAEP represents RSAES OAEP using SHA-1.
+	RSAOAEP KeyAlgorithm = "RSA-OAEP"
+
+	// RSAOAEP256 represents RSAES OAEP using SHA-256.
+	RSAOAEP256 KeyAlgorithm = "RSA-OAEP-256"
+
+	// A128KW represents AES key wrap using a 128-bit key.
+	A128KW KeyAlgorithm = "A128KW"
+
+	// A256KW represents AES key wrap using a 256-bit key.
+	A256KW KeyAlgorithm = "A256KW"
+
+	// Direct represents direct use of a shared symmetric key.
+	Direct KeyAlgorithm = "dir"
+
+	// ECDHES represents direct ECDH-ES key agreement.
+	ECDHES KeyAlgorithm = "ECDH-ES"
+
+	// ECDHESA128KW represents ECDH-ES key agreement with A128KW key wrap.
+	ECDHESA128KW KeyAlgorithm = "ECDH-ES+A128KW"
+
+	// ECDHESA256KW represents ECDH-ES key agreement with A256KW key wrap.
+	ECDHESA256KW KeyAlgorithm = "ECDH-ES+A256KW"
+)
+
+// ContentEncryption is used to define the algorithm used to encrypt the
+// content.
+type ContentEncryption string
+
+const (
+	// A128GCM represents AES GCM using a 128-bit key.
+	A128GCM ContentEncryption = "A128GCM"
+
+	// A256GCM represents AES GCM using a 256-bit key.
+	A256GCM ContentEncryption = "A256GCM"
+
+	// A128CBCHS256 represents AES CBC using a 128-bit key with HMAC SHA-256.
+	A128CBCHS256 ContentEncryption = "A128CBC-HS256"
+
+	// A256CBCHS512 represents AES CBC using a 256-bit key with HMAC SHA-512.
+	A256CBCHS512 ContentEncryption = "A256CBC-HS512"
+)
+
+var (
+	// ErrAlgorithmMismatch is returned when the "alg" header does not match the expected algorithm.
+	ErrAlgorithmMismatch = errors.New("jwt/jwe: algorithm mismatch")
+
+	// ErrDecryptFailed is returned when the token cannot be decrypted or authenticated.
+	ErrDecryptFailed = errors.New("jwt/jwe: decryption failed")
+
+	// ErrInvalidKey is returned when the key is not valid for the algorithm.
+	ErrInvalidKey = errors.New("jwt/jwe: invalid key")
+
+	// ErrInvalidToken is returned when the token structure is invalid.
+	ErrInvalidToken = errors.New("jwt/jwe: invalid token")
+
+	// ErrUnsupportedAlgorithm is returned when the key algorithm isn't implemented.
+	ErrUnsupportedAlgorithm = errors.New("jwt/jwe: unsupported algorithm")
+
+	// ErrUnsupportedCompression is returned when the token has a "zip" header.
+	ErrUnsupportedCompression = errors.New("jwt/jwe: unsupported compression")
+
+	// ErrUnsupportedCritical is returned when the token has critical header parameters.
+	ErrUnsupportedCritical = errors.New("jwt/jwe: unsupported critical header")
+
+	// ErrUnsupportedEncryption is returned when the content encryption isn't implemented.
+	ErrUnsupportedEncryption = errors.New("jwt/jwe: unsupported content encryption")
+
+	// ErrUnsupportedKeyType is returned when the key is not a supported type.
+	ErrUnsupportedKeyType = errors.New("jwt/jwe: unsupported key type")
+)
+
+// Header contains the protected header of an encrypted token.
+type Header struct {
+	// Algorithm is the algorithm used for the content encryption key.
+	Algorithm KeyAlgorithm `json:"alg"`
+
+	// Encryption is the algorithm used to encrypt the content.
+	Encryption ContentEncryption `json:"enc"`
+
+	// KeyID identifies the key used for the content encryption key.
+	KeyID string `json:"kid,omitempty"`
+
+	// Type is the media type of the complete token.
+	Type string `json:"typ,omitempty"`
+
+	// ContentType is the media type of the content, such as "JWT" for a
+	// nested token.
+	ContentType string `json:"cty,omitempty"`
+
+	// EphemeralPublicKey is the public key created by the sender for ECDH-ES
+	// key agreement. It is set by Encrypt.
+	EphemeralPublicKey *jwk.Key `json:"epk,omitempty"`
+
+	// PartyUInfo contains base64url encoded information about the sender for
+	// ECDH-ES key agreement.
+	PartyUInfo string `json:"apu,omitempty"`
+
+	// PartyVInfo contains base64url encoded information about the recipient
+	// for ECDH-ES key agreement.
+	PartyVInfo string `json:"apv,omitempty"`
+
+	// Compression is the algorithm used to compress the plaintext before
+	// encryption. It is not supported and tokens that set it are rejected.
+	Compression string `json:"zip,omitempty"`
+
+	// Critical lists the header parameters that must be understood. It is not
+	// supported and tokens listing any parameter are rejected.
+	Critical []string `json:"crit,omitempty"`
+}
+
+// Encrypt encrypts the plaintext using the algorithms in the header and
+// returns the token in the compact serialization.
+//
+// The key parameter type depends on the key algorithm. RSA-OAEP accepts the
+// same public key types as the rsa package, ECDH-ES accepts the same public
+// key types as the ecdsa package, and the AES key wrap and direct algorithms
+// accept []byte, string and *jwk.Key symmetric keys of the required length.
+func Encrypt(plaintext []byte, header Header, key interface{}) (string, error) {
+	size, ok := contentKeySizes[header.Encryption]
+	if !ok {
+		return "", ErrUnsupportedEncryption
+	}
+
+	if len(header.Compression) > 0 {
+		return "", ErrUnsupportedCompression
+	}
+	if len(header.Critical) > 0 {
+		return "", ErrUnsupportedCritical
+	}
+
+	cek, encryptedKey, err := encryptKey(&header, size, key)
+	if err != nil {
+		return "", err
+	}
+
+	b, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	protected := base64.RawURLEncoding.EncodeToString(b)
+
+	iv, ciphertext, tag, err := encryptContent(header.Encryption, cek, plaintext, []byte(protected))
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf(
+		"%s.%s.%s.%s.%s",
+		protected,
+		base64.RawURLEncoding.EncodeToString(encryptedKey),
+		base64.RawURLEncoding.EncodeToString(iv),
+		base64.RawURLEncoding.EncodeToString(ciphertext),
+		base64.RawURLEncoding.EncodeToString(tag),
+	), nil
+}
+
+// Decrypt decrypts a token in the compact serialization using the given key,
+// and returns the plaintext along with the decoded header.
+//
+// The "alg" header of the token must match the given algorithm, in order to
+// ensure that a token is what the server expects. The key parameter type
+// mirrors Encrypt, but private keys are required for RSA-OAEP and ECDH-ES.
+func Decrypt(token string, algorithm KeyAlgorithm, key interface{}) ([]byte, *Header, error) {
+	s := strings.Split(token, ".")
+	if len(s) != 5 {
+		return nil, nil, ErrInvalidToken
+	}
+
+	header, err := decodeHeader(s[0])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if header.Algorithm != algorithm {
+		return nil, nil, ErrAlgorithmMismatch
+	}
+
+	size, ok := contentKeySizes[header.Encryption]
+	if !ok {
+		return nil, nil, ErrUnsupportedEncryption
+	}
+
+	parts := make([][]byte, 4)
+	for i := range parts {
+		if parts[i], err = base64.RawURLEncoding.DecodeString(s[i+1]); err != nil {
+			return nil, nil, ErrInvalidToken
+		}
+	}
+
+	cek, err := decryptKey(header, size, parts[0], key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	plaintext, err := decryptContent(header.Encryption, cek, parts[1], parts[2], parts[3], []byte(s[0]))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return plaintext, header, nil
+}
+
+// decodeHeader decodes the protected header of a token.
+func decodeHeader(s string) (*Header, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	header := new(Header)
+	if err := json.Unmarshal(b, header); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	if len(header.Compression) > 0 {
+		return nil, ErrUnsupportedCompression
+	}
+	if len(header.Critical) > 0 {
+		return nil, ErrUnsupportedCritical
+	}
+
+	return header, nil
+}
@@ -0,0 +1,268 @@
+package jwe
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"gopkg.in/zhevron/jwt.v1/jwk"
+)
+
+var Plaintext = []byte("Live long and prosper.")
+
+// KeyRFC7516 is the A128KW key used in RFC 7516 appendix A.3.
+var KeyRFC7516, _ = base64.RawURLEncoding.DecodeString("GawgguFyGrWKav7AX4VKUg")
+
+var TokenRFC7516 = "eyJhbGciOiJBMTI4S1ciLCJlbmMiOiJBMTI4Q0JDLUhTMjU2In0.6KB707dM9YTIgHtLvtgWQ8mKwboJW3of9locizkDTHzBC2IlrT1oOQ.AxY8DCtDaGlsbGljb3RoZQ.KDlTtXchhZTGufMYmOYGS4HffxPSUrfmqCHXaI9wOGY.U0m_YmjN04DJvceFICbCVQ"
+
+func TestWrapKey_RFC3394(t *testing.T) {
+	kek, _ := hex.DecodeString("000102030405060708090A0B0C0D0E0F")
+	key, _ := hex.DecodeString("00112233445566778899AABBCCDDEEFF")
+	expected, _ := hex.DecodeString("1FA68B0A8112B447AEF34BD8FB5A7B829D3E862371D2CFE5")
+
+	wrapped, err := wrapKey(kek, key)
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+	if !bytes.Equal(wrapped, expected) {
+		t.Fatalf("expected %x, got %x", expected, wrapped)
+	}
+
+	unwrapped, err := unwrapKey(kek, wrapped)
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+	if !bytes.Equal(unwrapped, key) {
+		t.Fatalf("expected %x, got %x", key, unwrapped)
+	}
+}
+
+func TestUnwrapKey_Fail(t *testing.T) {
+	kek, _ := hex.DecodeString("000102030405060708090A0B0C0D0E0F")
+	wrapped, _ := hex.DecodeString("1FA68B0A8112B447AEF34BD8FB5A7B829D3E862371D2CFE6")
+	if _, err := unwrapKey(kek, wrapped); err != ErrDecryptFailed {
+		t.Fatalf("expected %#q, got %#q", ErrDecryptFailed, err)
+	}
+}
+
+func TestDecrypt_RFC7516(t *testing.T) {
+	plaintext, header, err := Decrypt(TokenRFC7516, A128KW, KeyRFC7516)
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+	if !bytes.Equal(plaintext, Plaintext) {
+		t.Fatalf("expected %#q, got %#q", Plaintext, plaintext)
+	}
+	if header.Encryption != A128CBCHS256 {
+		t.Fatalf("expected %#q, got %#q", A128CBCHS256, header.Encryption)
+	}
+}
+
+func TestConcatKDF_RFC7518(t *testing.T) {
+	// The key agreement example from RFC 7518 appendix C.
+	alice, err := jwk.Parse([]byte(`{"kty":"EC","crv":"P-256","x":"gI0GAILBdu7T53akrFmMyGcsF3n5dO7MmwNBHKW5SV0","y":"SLW_xSffzlPWrHEVI30DHM_4egVwt3NQqeUD7nMFpps","d":"0_NxaRPUMQoAJt50Gz8YiTr8gRTwyEaCumd-MToTmIo"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bob, err := jwk.Parse([]byte(`{"kty":"EC","crv":"P-256","x":"weNJy2HscCSM6AEDTDg04biOvhFhyyWvOHQfeF_PxMQ","y":"e8lnCO-AlStT-NJVX-crhB7QRYhiix03illJOVAOyck","d":"VEmDZpDXXK8p8N0Cndsxs924q6nS1RXFASRl6BfUqdw"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header := &Header{
+		Algorithm:  ECDHES,
+		Encryption: A128GCM,
+		PartyUInfo: "QWxpY2U",
+		PartyVInfo: "Qm9i",
+	}
+	key, err := agreeKey(header, 16, bob.Key.(*ecdsa.PrivateKey), alice.Public().Key.(*ecdsa.PublicKey))
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+	expected := "VqqN6vgjbSBcIijNcacQGg"
+	if base64.RawURLEncoding.EncodeToString(key) != expected {
+		t.Fatalf("expected %#q, got %#q", expected, base64.RawURLEncoding.EncodeToString(key))
+	}
+}
+
+func TestEncrypt(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys := map[KeyAlgorithm][2]interface{}{
+		RSAOAEP:      {&rsaKey.PublicKey, rsaKey},
+		RSAOAEP256:   {&rsaKey.PublicKey, rsaKey},
+		A128KW:       {make([]byte, 16), make([]byte, 16)},
+		A256KW:       {make([]byte, 32), make([]byte, 32)},
+		ECDHES:       {&ecKey.PublicKey, ecKey},
+		ECDHESA128KW: {&ecKey.PublicKey, ecKey},
+		ECDHESA256KW: {&ecKey.PublicKey, ecKey},
+	}
+
+	for alg, k := range keys {
+		for enc := range contentKeySizes {
+			header := Header{Algorithm: alg, Encryption: enc, KeyID: "MyKey"}
+			token, err := Encrypt(Plaintext, header, k[0])
+			if err != nil {
+				t.Fatalf("%s %s: expected nil, got %#q", alg, enc, err)
+			}
+
+			plaintext, decoded, err := Decrypt(token, alg, k[1])
+			if err != nil {
+				t.Fatalf("%s %s: expected nil, got %#q", alg, enc, err)
+			}
+			if !bytes.Equal(plaintext, Plaintext) {
+				t.Fatalf("%s %s: expected %#q, got %#q", alg, enc, Plaintext, plaintext)
+			}
+			if decoded.KeyID != "MyKey" {
+				t.Fatalf("%s %s: expected %#q, got %#q", alg, enc, "MyKey", decoded.KeyID)
+			}
+		}
+	}
+}
+
+func TestEncrypt_Direct(t *testing.T) {
+	for enc, size := range contentKeySizes {
+		key := make([]byte, size)
+		token, err := Encrypt(Plaintext, Header{Algorithm: Direct, Encryption: enc}, key)
+		if err != nil {
+			t.Fatalf("%s: expected nil, got %#q", enc, err)
+		}
+		if s := strings.Split(token, "."); len(s[1]) > 0 {
+			t.Fatalf("%s: expected empty encrypted key, got %#q", enc, s[1])
+		}
+		plaintext, _, err := Decrypt(token, Direct, key)
+		if err != nil {
+			t.Fatalf("%s: expected nil, got %#q", enc, err)
+		}
+		if !bytes.Equal(plaintext, Plaintext) {
+			t.Fatalf("%s: expected %#q, got %#q", enc, Plaintext, plaintext)
+		}
+	}
+}
+
+func TestEncrypt_InvalidKeySize(t *testing.T) {
+	if _, err := Encrypt(Plaintext, Header{Algorithm: A128KW, Encryption: A128GCM}, make([]byte, 32)); err != ErrInvalidKey {
+		t.Fatalf("expected %#q, got %#q", ErrInvalidKey, err)
+	}
+	if _, err := Encrypt(Plaintext, Header{Algorithm: Direct, Encryption: A256GCM}, make([]byte, 16)); err != ErrInvalidKey {
+		t.Fatalf("expected %#q, got %#q", ErrInvalidKey, err)
+	}
+}
+
+func TestEncrypt_Unsupported(t *testing.T) {
+	if _, err := Encrypt(Plaintext, Header{Algorithm: A128KW, Encryption: "INVALID"}, KeyRFC7516); err != ErrUnsupportedEncryption {
+		t.Fatalf("expected %#q, got %#q", ErrUnsupportedEncryption, err)
+	}
+	if _, err := Encrypt(Plaintext, Header{Algorithm: "INVALID", Encryption: A128GCM}, KeyRFC7516); err != ErrUnsupportedAlgorithm {
+		t.Fatalf("expected %#q, got %#q", ErrUnsupportedAlgorithm, err)
+	}
+	if _, err := Encrypt(Plaintext, Header{Algorithm: A128KW, Encryption: A128GCM}, 0); err != ErrUnsupportedKeyType {
+		t.Fatalf("expected %#q, got %#q", ErrUnsupportedKeyType, err)
+	}
+	if _, err := Encrypt(Plaintext, Header{Algorithm: A128KW, Encryption: A128GCM, Compression: "DEF"}, KeyRFC7516); err != ErrUnsupportedCompression {
+		t.Fatalf("expected %#q, got %#q", ErrUnsupportedCompression, err)
+	}
+}
+
+func TestDecrypt_AlgorithmMismatch(t *testing.T) {
+	if _, _, err := Decrypt(TokenRFC7516, Direct, KeyRFC7516); err != ErrAlgorithmMismatch {
+		t.Fatalf("expected %#q, got %#q", ErrAlgorithmMismatch, err)
+	}
+}
+
+func TestDecrypt_InvalidToken(t *testing.T) {
+	if _, _, err := Decrypt("INVALID", A128KW, KeyRFC7516); err != ErrInvalidToken {
+		t.Fatalf("expected %#q, got %#q", ErrInvalidToken, err)
+	}
+	s := strings.Split(TokenRFC7516, ".")
+	s[2] = "!"
+	if _, _, err := Decrypt(strings.Join(s, "."), A128KW, KeyRFC7516); err != ErrInvalidToken {
+		t.Fatalf("expected %#q, got %#q", ErrInvalidToken, err)
+	}
+}
+
+func TestDecrypt_TamperedTag(t *testing.T) {
+	for _, enc := range []ContentEncryption{A128GCM, A128CBCHS256} {
+		token, err := Encrypt(Plaintext, Header{Algorithm: A128KW, Encryption: enc}, KeyRFC7516)
+		if err != nil {
+			t.Fatalf("expected nil, got %#q", err)
+		}
+		s := strings.Split(token, ".")
+		s[4] = strings.Repeat("A", len(s[4]))
+		if _, _, err := Decrypt(strings.Join(s, "."), A128KW, KeyRFC7516); err != ErrDecryptFailed {
+			t.Fatalf("%s: expected %#q, got %#q", enc, ErrDecryptFailed, err)
+		}
+	}
+}
+
+func TestDecrypt_TamperedHeader(t *testing.T) {
+	token, err := Encrypt(Plaintext, Header{Algorithm: A128KW, Encryption: A128GCM}, KeyRFC7516)
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+	s := strings.Split(token, ".")
+	// {"alg":"A128KW","enc":"A128GCM","kid":"x"}
+	s[0] = "eyJhbGciOiJBMTI4S1ciLCJlbmMiOiJBMTI4R0NNIiwia2lkIjoieCJ9"
+	if _, _, err := Decrypt(strings.Join(s, "."), A128KW, KeyRFC7516); err != ErrDecryptFailed {
+		t.Fatalf("expected %#q, got %#q", ErrDecryptFailed, err)
+	}
+}
+
+func TestDecrypt_WrongRSAKey(t *testing.T) {
+	k1, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k2, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := Encrypt(Plaintext, Header{Algorithm: RSAOAEP256, Encryption: A256GCM}, &k1.PublicKey)
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+	if _, _, err := Decrypt(token, RSAOAEP256, k2); err != ErrDecryptFailed {
+		t.Fatalf("expected %#q, got %#q", ErrDecryptFailed, err)
+	}
+}
+
+func TestDecrypt_UnsupportedCompression(t *testing.T) {
+	// {"alg":"dir","enc":"A128GCM","zip":"DEF"}
+	token := "eyJhbGciOiJkaXIiLCJlbmMiOiJBMTI4R0NNIiwiemlwIjoiREVGIn0..AAAAAAAAAAAAAAAA.AA.AAAAAAAAAAAAAAAAAAAAAA"
+	if _, _, err := Decrypt(token, Direct, make([]byte, 16)); err != ErrUnsupportedCompression {
+		t.Fatalf("expected %#q, got %#q", ErrUnsupportedCompression, err)
+	}
+}
+
+func TestDecrypt_UnsupportedCritical(t *testing.T) {
+	// {"alg":"dir","enc":"A128GCM","crit":["exp"],"exp":0}
+	token := "eyJhbGciOiJkaXIiLCJlbmMiOiJBMTI4R0NNIiwiY3JpdCI6WyJleHAiXSwiZXhwIjowfQ..AAAAAAAAAAAAAAAA.AA.AAAAAAAAAAAAAAAAAAAAAA"
+	if _, _, err := Decrypt(token, Direct, make([]byte, 16)); err != ErrUnsupportedCritical {
+		t.Fatalf("expected %#q, got %#q", ErrUnsupportedCritical, err)
+	}
+}
+
+func TestDecrypt_ECDHMissingEphemeralKey(t *testing.T) {
+	k, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// {"alg":"ECDH-ES","enc":"A128GCM"}
+	token := "eyJhbGciOiJFQ0RILUVTIiwiZW5jIjoiQTEyOEdDTSJ9..AAAAAAAAAAAAAAAA.AA.AAAAAAAAAAAAAAAAAAAAAA"
+	if _, _, err := Decrypt(token, ECDHES, k); err != ErrInvalidToken {
+		t.Fatalf("expected %#q, got %#q", ErrInvalidToken, err)
+	}
+}
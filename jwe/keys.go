@@ -0,0 +1,286 @@
+package jwe
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"hash"
+
+	jwtecdsa "gopkg.in/zhevron/jwt.v1/ecdsa"
+	"gopkg.in/zhevron/jwt.v1/jwk"
+	jwtrsa "gopkg.in/zhevron/jwt.v1/rsa"
+)
+
+// keyWrapSizes is used to determine the key encryption key size of the
+// algorithms using AES key wrap.
+var keyWrapSizes = map[KeyAlgorithm]int{
+	A128KW:       16,
+	A256KW:       32,
+	ECDHESA128KW: 16,
+	ECDHESA256KW: 32,
+}
+
+// encryptKey returns the content encryption key along with the encrypted key
+// for the recipient. The header is updated with the ephemeral public key when
+// using ECDH-ES.
+func encryptKey(header *Header, size int, key interface{}) ([]byte, []byte, error) {
+	switch header.Algorithm {
+	case Direct:
+		k, err := symmetricKey(key, size)
+		if err != nil {
+			return nil, nil, err
+		}
+		return k, nil, nil
+
+	case A128KW, A256KW:
+		kek, err := symmetricKey(key, keyWrapSizes[header.Algorithm])
+		if err != nil {
+			return nil, nil, err
+		}
+		return wrapRandomKey(kek, size)
+
+	case RSAOAEP, RSAOAEP256:
+		k, err := jwtrsa.ParsePublicKey(key)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		cek, err := randomKey(size)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		encryptedKey, err := rsa.EncryptOAEP(oaepHash(header.Algorithm), rand.Reader, k, cek, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		return cek, encryptedKey, nil
+
+	case ECDHES, ECDHESA128KW, ECDHESA256KW:
+		k, err := jwtecdsa.ParsePublicKey(key)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		epk, err := ecdsa.GenerateKey(k.Curve, rand.Reader)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		header.EphemeralPublicKey, err = jwk.NewKey(&epk.PublicKey)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		derived, err := agreeKey(header, size, epk, k)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if header.Algorithm == ECDHES {
+			return derived, nil, nil
+		}
+		return wrapRandomKey(derived, size)
+	}
+
+	return nil, nil, ErrUnsupportedAlgorithm
+}
+
+// decryptKey returns the content encryption key for the token.
+func decryptKey(header *Header, size int, encryptedKey []byte, key interface{}) ([]byte, error) {
+	switch header.Algorithm {
+	case Direct:
+		if len(encryptedKey) > 0 {
+			return nil, ErrInvalidToken
+		}
+		return symmetricKey(key, size)
+
+	case A128KW, A256KW:
+		kek, err := symmetricKey(key, keyWrapSizes[header.Algorithm])
+		if err != nil {
+			return nil, err
+		}
+		return unwrapContentKey(kek, encryptedKey, size)
+
+	case RSAOAEP, RSAOAEP256:
+		k, err := jwtrsa.ParsePrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+
+		// A random key is used when decryption fails, so that the failure
+		// cannot be told apart from a failure to decrypt the content.
+		cek, err := rsa.DecryptOAEP(oaepHash(header.Algorithm), nil, k, encryptedKey, nil)
+		if err != nil || len(cek) != size {
+			return randomKey(size)
+		}
+		return cek, nil
+
+	case ECDHES, ECDHESA128KW, ECDHESA256KW:
+		k, err := jwtecdsa.ParsePrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+
+		if header.EphemeralPublicKey == nil {
+			return nil, ErrInvalidToken
+		}
+		epk, ok := header.EphemeralPublicKey.Key.(*ecdsa.PublicKey)
+		if !ok {
+			return nil, ErrInvalidToken
+		}
+
+		derived, err := agreeKey(header, size, k, epk)
+		if err != nil {
+			return nil, err
+		}
+
+		if header.Algorithm == ECDHES {
+			if len(encryptedKey) > 0 {
+				return nil, ErrInvalidToken
+			}
+			return derived, nil
+		}
+		return unwrapContentKey(derived, encryptedKey, size)
+	}
+
+	return nil, ErrUnsupportedAlgorithm
+}
+
+// agreeKey performs ECDH key agreement and derives a key using the Concat KDF
+// as described in RFC 7518 section 4.6.2.
+func agreeKey(header *Header, size int, private *ecdsa.PrivateKey, public *ecdsa.PublicKey) ([]byte, error) {
+	priv, err := private.ECDH()
+	if err != nil {
+		return nil, ErrInvalidKey
+	}
+
+	pub, err := public.ECDH()
+	if err != nil {
+		return nil, ErrInvalidKey
+	}
+
+	z, err := priv.ECDH(pub)
+	if err != nil {
+		return nil, ErrInvalidKey
+	}
+
+	apu, err := base64.RawURLEncoding.DecodeString(header.PartyUInfo)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	apv, err := base64.RawURLEncoding.DecodeString(header.PartyVInfo)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	// The algorithm ID is "enc" for direct key agreement and "alg" when the
+	// derived key is used for key wrapping.
+	algorithmID := string(header.Encryption)
+	if kwSize, ok := keyWrapSizes[header.Algorithm]; ok {
+		algorithmID = string(header.Algorithm)
+		size = kwSize
+	}
+
+	return concatKDF(z, []byte(algorithmID), apu, apv, size), nil
+}
+
+// concatKDF derives a key of the given size using the Concat KDF from NIST
+// SP 800-56A with SHA-256.
+func concatKDF(z, algorithmID, apu, apv []byte, size int) []byte {
+	var otherInfo []byte
+	for _, v := range [][]byte{algorithmID, apu, apv} {
+		otherInfo = binary.BigEndian.AppendUint32(otherInfo, uint32(len(v)))
+		otherInfo = append(otherInfo, v...)
+	}
+	otherInfo = binary.BigEndian.AppendUint32(otherInfo, uint32(size*8))
+
+	var key []byte
+	for counter := uint32(1); len(key) < size; counter++ {
+		h := sha256.New()
+		h.Write(binary.BigEndian.AppendUint32(nil, counter))
+		h.Write(z)
+		h.Write(otherInfo)
+		key = h.Sum(key)
+	}
+
+	return key[:size]
+}
+
+// oaepHash returns the hash used by the RSA-OAEP algorithm.
+func oaepHash(algorithm KeyAlgorithm) hash.Hash {
+	if algorithm == RSAOAEP256 {
+		return sha256.New()
+	}
+
+	return sha1.New()
+}
+
+// wrapRandomKey creates a random content encryption key and wraps it.
+func wrapRandomKey(kek []byte, size int) ([]byte, []byte, error) {
+	cek, err := randomKey(size)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	wrapped, err := wrapKey(kek, cek)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return cek, wrapped, nil
+}
+
+// unwrapContentKey unwraps the content encryption key and checks its size.
+func unwrapContentKey(kek, wrapped []byte, size int) ([]byte, error) {
+	cek, err := unwrapKey(kek, wrapped)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(cek) != size {
+		return nil, ErrDecryptFailed
+	}
+
+	return cek, nil
+}
+
+// randomKey creates a random key of the given size.
+func randomKey(size int) ([]byte, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// symmetricKey returns the symmetric key from the secret, and checks that it
+// has the given size.
+func symmetricKey(key interface{}, size int) ([]byte, error) {
+	var k []byte
+	switch key.(type) {
+	case *jwk.Key:
+		return symmetricKey(key.(*jwk.Key).Key, size)
+
+	case []byte:
+		k = key.([]byte)
+
+	case string:
+		k = []byte(key.(string))
+
+	default:
+		return nil, ErrUnsupportedKeyType
+	}
+
+	if len(k) != size {
+		return nil, ErrInvalidKey
+	}
+
+	return k, nil
+}
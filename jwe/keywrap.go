@@ -0,0 +1,77 @@
+package jwe
+
+import (
+	"crypto/aes"
+	"crypto/subtle"
+	"encoding/binary"
+)
+
+// keyWrapIV is the default initial value from RFC 3394 section 2.2.3.1.
+var keyWrapIV = []byte{0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6}
+
+// wrapKey wraps the key using the AES key wrap algorithm from RFC 3394.
+func wrapKey(kek, key []byte) ([]byte, error) {
+	if len(key) < 16 || len(key)%8 != 0 {
+		return nil, ErrInvalidKey
+	}
+
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, ErrInvalidKey
+	}
+
+	n := len(key) / 8
+	b := make([]byte, 8+len(key))
+	copy(b, keyWrapIV)
+	copy(b[8:], key)
+
+	buf := make([]byte, 16)
+	for j := 0; j < 6; j++ {
+		for i := 1; i <= n; i++ {
+			copy(buf, b[:8])
+			copy(buf[8:], b[i*8:i*8+8])
+			block.Encrypt(buf, buf)
+
+			t := uint64(n*j + i)
+			binary.BigEndian.PutUint64(b[:8], binary.BigEndian.Uint64(buf[:8])^t)
+			copy(b[i*8:], buf[8:])
+		}
+	}
+
+	return b, nil
+}
+
+// unwrapKey unwraps the key using the AES key wrap algorithm from RFC 3394.
+func unwrapKey(kek, wrapped []byte) ([]byte, error) {
+	if len(wrapped) < 24 || len(wrapped)%8 != 0 {
+		return nil, ErrDecryptFailed
+	}
+
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, ErrInvalidKey
+	}
+
+	n := len(wrapped)/8 - 1
+	b := make([]byte, len(wrapped))
+	copy(b, wrapped)
+
+	buf := make([]byte, 16)
+	for j := 5; j >= 0; j-- {
+		for i := n; i >= 1; i-- {
+			t := uint64(n*j + i)
+			binary.BigEndian.PutUint64(buf[:8], binary.BigEndian.Uint64(b[:8])^t)
+			copy(buf[8:], b[i*8:i*8+8])
+			block.Decrypt(buf, buf)
+
+			copy(b[:8], buf[:8])
+			copy(b[i*8:], buf[8:])
+		}
+	}
+
+	if subtle.ConstantTimeCompare(b[:8], keyWrapIV) != 1 {
+		return nil, ErrDecryptFailed
+	}
+
+	return b[8:], nil
+}
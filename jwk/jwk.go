@@ -0,0 +1,511 @@
+// Package jwk provides parsing and serialization of JSON Web Keys (RFC 7517).
+package jwk
+
+import (
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+)
+
+// KeyType is used to define the family of a key.
+type KeyType string
+
+const (
+	// RSA represents an RSA key.
+	RSA KeyType = "RSA"
+
+	// EC represents an elliptic curve key.
+	EC KeyType = "EC"
+
+	// Oct represents a symmetric key (octet sequence).
+	Oct KeyType = "oct"
+
+	// OKP represents an octet key pair (RFC 8037).
+	OKP KeyType = "OKP"
+)
+
+const (
+	// MinRSAKeySize is the smallest RSA modulus in bits accepted by Parse.
+	MinRSAKeySize = 1024
+
+	// MaxRSAKeySize is the largest RSA modulus in bits accepted by Parse.
+	MaxRSAKeySize = 16384
+)
+
+var (
+	// ErrInvalidKey is returned when the key members are missing or invalid.
+	ErrInvalidKey = errors.New("jwt/jwk: invalid key")
+
+	// ErrUnsupportedKeyType is returned when the key type is not supported.
+	ErrUnsupportedKeyType = errors.New("jwt/jwk: unsupported key type")
+
+	// ErrUnsupportedCurve is returned when the curve is not supported.
+	ErrUnsupportedCurve = errors.New("jwt/jwk: unsupported curve")
+)
+
+// Key contains a JSON Web Key.
+//
+// The Key field holds the key material, which is one of *rsa.PrivateKey,
+// *rsa.PublicKey, *ecdsa.PrivateKey, *ecdsa.PublicKey, ed25519.PrivateKey,
+// ed25519.PublicKey or []byte for symmetric keys.
+type Key struct {
+	KeyType   KeyType
+	Use       string
+	KeyOps    []string
+	Algorithm string
+	KeyID     string
+	Key       interface{}
+}
+
+// rawKey is used for internal mapping of the JSON members of a key.
+type rawKey struct {
+	KeyType   KeyType  `json:"kty"`
+	Use       string   `json:"use,omitempty"`
+	KeyOps    []string `json:"key_ops,omitempty"`
+	Algorithm string   `json:"alg,omitempty"`
+	KeyID     string   `json:"kid,omitempty"`
+	Curve     string   `json:"crv,omitempty"`
+	X         string   `json:"x,omitempty"`
+	Y         string   `json:"y,omitempty"`
+	N         string   `json:"n,omitempty"`
+	E         string   `json:"e,omitempty"`
+	D         string   `json:"d,omitempty"`
+	P         string   `json:"p,omitempty"`
+	Q         string   `json:"q,omitempty"`
+	DP        string   `json:"dp,omitempty"`
+	DQ        string   `json:"dq,omitempty"`
+	QI        string   `json:"qi,omitempty"`
+	K         string   `json:"k,omitempty"`
+}
+
+// NewKey creates a new Key from the given key material. The key type is
+// determined from the type of the key material.
+func NewKey(key interface{}) (*Key, error) {
+	kty, err := keyType(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Key{
+		KeyType: kty,
+		Key:     key,
+	}, nil
+}
+
+// Parse parses a single JSON Web Key.
+func Parse(data []byte) (*Key, error) {
+	k := new(Key)
+	if err := json.Unmarshal(data, k); err != nil {
+		return nil, err
+	}
+
+	return k, nil
+}
+
+// Public returns a copy of the key containing only the public key material.
+// Symmetric keys are returned as is, since they have no public part.
+func (k *Key) Public() *Key {
+	pub := *k
+
+	switch key := k.Key.(type) {
+	case *rsa.PrivateKey:
+		pub.Key = &key.PublicKey
+
+	case *ecdsa.PrivateKey:
+		pub.Key = &key.PublicKey
+
+	case ed25519.PrivateKey:
+		pub.Key = key.Public()
+	}
+
+	return &pub
+}
+
+// IsPrivate checks if the key contains private or symmetric key material.
+func (k *Key) IsPrivate() bool {
+	switch k.Key.(type) {
+	case *rsa.PrivateKey, *ecdsa.PrivateKey, ed25519.PrivateKey, []byte:
+		return true
+	}
+
+	return false
+}
+
+// MarshalJSON encodes the key as a JSON Web Key.
+func (k *Key) MarshalJSON() ([]byte, error) {
+	kty, err := keyType(k.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := rawKey{
+		KeyType:   kty,
+		Use:       k.Use,
+		KeyOps:    k.KeyOps,
+		Algorithm: k.Algorithm,
+		KeyID:     k.KeyID,
+	}
+
+	switch key := k.Key.(type) {
+	case *rsa.PrivateKey:
+		raw.N = encodeInt(key.N)
+		raw.E = encodeInt(big.NewInt(int64(key.E)))
+		raw.D = encodeInt(key.D)
+		if len(key.Primes) == 0 {
+			break
+		}
+		if len(key.Primes) != 2 {
+			return nil, ErrInvalidKey
+		}
+		// The CRT values are computed here rather than with Precompute, which
+		// would modify a key that may be in use by other goroutines.
+		p, q := key.Primes[0], key.Primes[1]
+		one := big.NewInt(1)
+		qi := new(big.Int).ModInverse(q, p)
+		if qi == nil {
+			return nil, ErrInvalidKey
+		}
+		raw.P = encodeInt(p)
+		raw.Q = encodeInt(q)
+		raw.DP = encodeInt(new(big.Int).Mod(key.D, new(big.Int).Sub(p, one)))
+		raw.DQ = encodeInt(new(big.Int).Mod(key.D, new(big.Int).Sub(q, one)))
+		raw.QI = encodeInt(qi)
+
+	case *rsa.PublicKey:
+		raw.N = encodeInt(key.N)
+		raw.E = encodeInt(big.NewInt(int64(key.E)))
+
+	case *ecdsa.PrivateKey:
+		if err := encodeEC(&raw, &key.PublicKey); err != nil {
+			return nil, err
+		}
+		raw.D = encodeFixed(key.D, curveSize(key.Curve))
+
+	case *ecdsa.PublicKey:
+		if err := encodeEC(&raw, key); err != nil {
+			return nil, err
+		}
+
+	case ed25519.PrivateKey:
+		raw.Curve = "Ed25519"
+		raw.X = encode(key.Public().(ed25519.PublicKey))
+		raw.D = encode(key.Seed())
+
+	case ed25519.PublicKey:
+		raw.Curve = "Ed25519"
+		raw.X = encode(key)
+
+	case []byte:
+		raw.K = encode(key)
+	}
+
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON decodes a JSON Web Key.
+func (k *Key) UnmarshalJSON(data []byte) error {
+	var raw rawKey
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	var key interface{}
+	var err error
+
+	switch raw.KeyType {
+	case RSA:
+		key, err = decodeRSA(&raw)
+
+	case EC:
+		key, err = decodeEC(&raw)
+
+	case OKP:
+		key, err = decodeOKP(&raw)
+
+	case Oct:
+		key, err = decode(raw.K)
+		if err == nil && len(key.([]byte)) == 0 {
+			err = ErrInvalidKey
+		}
+
+	default:
+		err = ErrUnsupportedKeyType
+	}
+
+	if err != nil {
+		return err
+	}
+
+	*k = Key{
+		KeyType:   raw.KeyType,
+		Use:       raw.Use,
+		KeyOps:    raw.KeyOps,
+		Algorithm: raw.Algorithm,
+		KeyID:     raw.KeyID,
+		Key:       key,
+	}
+
+	return nil
+}
+
+// keyType returns the key type for the given key material.
+func keyType(key interface{}) (KeyType, error) {
+	switch key.(type) {
+	case *rsa.PrivateKey, *rsa.PublicKey:
+		return RSA, nil
+
+	case *ecdsa.PrivateKey, *ecdsa.PublicKey:
+		return EC, nil
+
+	case ed25519.PrivateKey, ed25519.PublicKey:
+		return OKP, nil
+
+	case []byte:
+		return Oct, nil
+	}
+
+	return "", ErrUnsupportedKeyType
+}
+
+// decodeRSA decodes the members of an RSA key.
+func decodeRSA(raw *rawKey) (interface{}, error) {
+	n, err := decodeInt(raw.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := decodeInt(raw.E)
+	if err != nil {
+		return nil, err
+	}
+	if n.BitLen() < MinRSAKeySize || n.BitLen() > MaxRSAKeySize {
+		return nil, ErrInvalidKey
+	}
+	if !e.IsInt64() || e.Int64() < 2 || e.Int64() > 1<<31-1 || e.Bit(0) == 0 {
+		return nil, ErrInvalidKey
+	}
+
+	pub := &rsa.PublicKey{
+		N: n,
+		E: int(e.Int64()),
+	}
+	if len(raw.D) == 0 {
+		return pub, nil
+	}
+
+	d, err := decodeInt(raw.D)
+	if err != nil {
+		return nil, err
+	}
+	if d.Sign() <= 0 || d.Cmp(n) >= 0 {
+		return nil, ErrInvalidKey
+	}
+
+	// RFC 7518 section 6.3.2 allows the prime factors to be omitted, in which
+	// case crypto/rsa falls back to the slower non-CRT computation. Such a key
+	// cannot be validated, so check that d inverts e with a test value.
+	if len(raw.P) == 0 && len(raw.Q) == 0 {
+		m := big.NewInt(2)
+		c := new(big.Int).Exp(m, big.NewInt(int64(pub.E)), n)
+		if new(big.Int).Exp(c, d, n).Cmp(m) != 0 {
+			return nil, ErrInvalidKey
+		}
+
+		return &rsa.PrivateKey{
+			PublicKey: *pub,
+			D:         d,
+		}, nil
+	}
+
+	p, err := decodeInt(raw.P)
+	if err != nil {
+		return nil, err
+	}
+	q, err := decodeInt(raw.Q)
+	if err != nil {
+		return nil, err
+	}
+
+	k := &rsa.PrivateKey{
+		PublicKey: *pub,
+		D:         d,
+		Primes:    []*big.Int{p, q},
+	}
+	if err := k.Validate(); err != nil {
+		return nil, ErrInvalidKey
+	}
+	k.Precompute()
+
+	return k, nil
+}
+
+// decodeEC decodes the members of an elliptic curve key.
+func decodeEC(raw *rawKey) (interface{}, error) {
+	c, ec, err := curve(raw.Curve)
+	if err != nil {
+		return nil, err
+	}
+
+	size := curveSize(c)
+	x, err := decode(raw.X)
+	if err != nil {
+		return nil, err
+	}
+	y, err := decode(raw.Y)
+	if err != nil {
+		return nil, err
+	}
+	if len(x) != size || len(y) != size {
+		return nil, ErrInvalidKey
+	}
+
+	// crypto/ecdh is used to check that the point is on the curve.
+	point := append([]byte{4}, x...)
+	point = append(point, y...)
+	if _, err := ec.NewPublicKey(point); err != nil {
+		return nil, ErrInvalidKey
+	}
+
+	pub := &ecdsa.PublicKey{
+		Curve: c,
+		X:     new(big.Int).SetBytes(x),
+		Y:     new(big.Int).SetBytes(y),
+	}
+	if len(raw.D) == 0 {
+		return pub, nil
+	}
+
+	d, err := decode(raw.D)
+	if err != nil {
+		return nil, err
+	}
+	if len(d) != size {
+		return nil, ErrInvalidKey
+	}
+
+	priv, err := ec.NewPrivateKey(d)
+	if err != nil {
+		return nil, ErrInvalidKey
+	}
+	if string(priv.PublicKey().Bytes()) != string(point) {
+		return nil, ErrInvalidKey
+	}
+
+	return &ecdsa.PrivateKey{
+		PublicKey: *pub,
+		D:         new(big.Int).SetBytes(d),
+	}, nil
+}
+
+// decodeOKP decodes the members of an octet key pair.
+func decodeOKP(raw *rawKey) (interface{}, error) {
+	if raw.Curve != "Ed25519" {
+		return nil, ErrUnsupportedCurve
+	}
+
+	x, err := decode(raw.X)
+	if err != nil {
+		return nil, err
+	}
+	if len(x) != ed25519.PublicKeySize {
+		return nil, ErrInvalidKey
+	}
+	if len(raw.D) == 0 {
+		return ed25519.PublicKey(x), nil
+	}
+
+	d, err := decode(raw.D)
+	if err != nil {
+		return nil, err
+	}
+	if len(d) != ed25519.SeedSize {
+		return nil, ErrInvalidKey
+	}
+
+	k := ed25519.NewKeyFromSeed(d)
+	if !k.Public().(ed25519.PublicKey).Equal(ed25519.PublicKey(x)) {
+		return nil, ErrInvalidKey
+	}
+
+	return k, nil
+}
+
+// encodeEC encodes the members of an elliptic curve public key.
+func encodeEC(raw *rawKey, k *ecdsa.PublicKey) error {
+	if k.Curve == nil {
+		return ErrInvalidKey
+	}
+
+	name := k.Curve.Params().Name
+	if _, _, err := curve(name); err != nil {
+		return err
+	}
+
+	size := curveSize(k.Curve)
+	raw.Curve = name
+	raw.X = encodeFixed(k.X, size)
+	raw.Y = encodeFixed(k.Y, size)
+
+	return nil
+}
+
+// curve returns the curve for the given "crv" value.
+func curve(name string) (elliptic.Curve, ecdh.Curve, error) {
+	switch name {
+	case "P-256":
+		return elliptic.P256(), ecdh.P256(), nil
+
+	case "P-384":
+		return elliptic.P384(), ecdh.P384(), nil
+
+	case "P-521":
+		return elliptic.P521(), ecdh.P521(), nil
+	}
+
+	return nil, nil, ErrUnsupportedCurve
+}
+
+// curveSize returns the number of bytes used to encode a coordinate.
+func curveSize(c elliptic.Curve) int {
+	return (c.Params().BitSize + 7) / 8
+}
+
+// encode encodes bytes using unpadded base64url.
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// encodeInt encodes an integer as unpadded base64url of its big-endian bytes.
+func encodeInt(i *big.Int) string {
+	return encode(i.Bytes())
+}
+
+// encodeFixed encodes an integer as unpadded base64url of its big-endian
+// bytes, left-padded with zeros to the given size.
+func encodeFixed(i *big.Int, size int) string {
+	return encode(i.FillBytes(make([]byte, size)))
+}
+
+// decode decodes a required unpadded base64url member.
+func decode(s string) ([]byte, error) {
+	if len(s) == 0 {
+		return nil, ErrInvalidKey
+	}
+
+	return base64.RawURLEncoding.DecodeString(s)
+}
+
+// decodeInt decodes a required unpadded base64url member as an integer.
+func decodeInt(s string) (*big.Int, error) {
+	b, err := decode(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,393 @@
+package jwk
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+)
+
+var RSAPrivateKey = `{"kty":"RSA","use":"sig","kid":"MyKey","n":"zshs12YDUivJD8MUdBTZ8wnzrWrLWxz0gAn03l72diG0yEzAsBH-s-mw293NJYGggWe2ueAE-5r252HNsKF7nACCm2FAk3kg-FOAQ0Fj7kxORRS8MSVK1eYBm1mIadpZs--ChgTbey_YJXaVPigDeWcuxX1yLGxOeR44Sp0yIA50Qbko2i33Ruxjcl_HDi8uYFj1Vj1SmXKH-HPJ0qyS4YSJHyLP9545BMGUyhTNxYVam1rbKVlQH4S4A0rI9Yuqf_9O29UQ9DwWDUV0QXfCgjRSKGVHaA7XH6L_67292RzcCqtPg7ELac9W9YKwipVoNgbIi0Ny5HIkO1YMj5X-fQ","e":"AQAB","d":"gw4D7YB6Glol1BePnwKYH7Du_7nvqI9xZrTovQbyrWwvZ8M9XFoLU3uI29B3dI3zbBCPfr68abqNQZh7BLdT4Etye4fnpY_flYNWh0mqTB2Qfbfmjj9UbryXyB22l2AL-j6SOt5ZPHnWQ-gkLoCtswPyYLhg5EbAdYLxqlh2rFlYiSe5kr2GtszXzzXdtQfkoNZ5mpjzj752RDa0Ll-hYQ67kI75qm9-8l4JgkaMZSTEO8t4jm8GKqWHOi68NjDltAIYhSvELFneWyW5Y0_vGTu4mdV8Vc4J2FXJz7lFb2Oo2bIvLKq6paxXlvoOOUjuRnb0KVXailmSORfFzJZwAQ","p":"9gOS-FUY-Nv96DHEIHZnrurkJO4z42WSWOPq-n8vCKsdjvwJZwHgnoiy1emu_3VLzSdELjczWp98wElkx_Het8cn8rrWD1UKiHlm7ltYXBuoxeKGtdxiPmkj_Yc94xU4tl_M7w69D28eRS8S1OiyEGMEsSibQAelrK_MVILFdNE","q":"1y0vo7v3QMH8MoAH2TaDGXBbzPLFG1PpnR25X9yjT29NKUdS7-dJYRSIQzavFXCpUnqyjmnRa0iJ1GylxG96SUY0X6JZrEV3sx5MDe30Soj-OU4Q7ADDR50OYTl8LI-R85aVviWCI7fJ79TsRptvIUt7OLcCHC4hGSfggWQ1ie0","dp":"FwS2l62-rGpJE5S0eSUbBm7L8finujsiulZ5Af8sc28vUNWcO5sdXTgFI6a9zQE4mnV2F6zqjSwnDAbR-zNSV3e28SsyJDUcyzAwxVSeq9-apwlO-W0pdBV6XJpu2_R8XfQQxL1oSy1mc6q35FvxbT8WjUzzWcZdZg7821txBkE","dq":"BAxclhcGOtirdwPDogmNg-ACSiPTI4V7orIZd7098VOlvv4RiGPwlHv_aExSFPQuq1eVnmpNP1h5B18X608xnMlOWPJD_6K68srCIYz1iKV1KvzWAqqtAd7pk1iyhmAZHd0aah5oiWV-zh1SaK2p9JVn9xQyXPddNgzzA1wwSa0","qi":"VS4EUJW2lwJzEeP4rRjqQ8cnajZ1PgxrFdx5h_7XV1rfNIAQYShMtAFKI7QKAm3bGoF-WCoGRe9EnHEix-RZXOPuJqkIT_MmpEI3VOhqOY8NUnS3lq8wY2jH47Q662L5Ghu0fOJm7LHek_BWGcRnYIYRXcQy_0cbvlpYMupFegc"}`
+var RSAPublicKey = `{"kty":"RSA","use":"sig","kid":"MyKey","n":"zshs12YDUivJD8MUdBTZ8wnzrWrLWxz0gAn03l72diG0yEzAsBH-s-mw293NJYGggWe2ueAE-5r252HNsKF7nACCm2FAk3kg-FOAQ0Fj7kxORRS8MSVK1eYBm1mIadpZs--ChgTbey_YJXaVPigDeWcuxX1yLGxOeR44Sp0yIA50Qbko2i33Ruxjcl_HDi8uYFj1Vj1SmXKH-HPJ0qyS4YSJHyLP9545BMGUyhTNxYVam1rbKVlQH4S4A0rI9Yuqf_9O29UQ9DwWDUV0QXfCgjRSKGVHaA7XH6L_67292RzcCqtPg7ELac9W9YKwipVoNgbIi0Ny5HIkO1YMj5X-fQ","e":"AQAB"}`
+
+// ECPrivateKey is the P-521 key from RFC 7520 section 3.2.
+var ECPrivateKey = `{"kty":"EC","kid":"bilbo.baggins@hobbiton.example","use":"sig","crv":"P-521","x":"AHKZLLOsCOzz5cY97ewNUajB957y-C-U88c3v13nmGZx6sYl_oJXu9A5RkTKqjqvjyekWF-7ytDyRXYgCF5cj0Kt","y":"AdymlHvOiLxXkEhayXQnNCvDX4h9htZaCJN34kfmC6pV5OhQHiraVySsUdaQkAgDPrwQrJmbnX9cwlGfP-HqHZR1","d":"AAhRON2r9cqXX1hg-RoI6R1tX5p2rUAYdmpHZoC1XNM56KtscrX6zbKipQrCW9CGZH3T4ubpnoTKLDYJ_fF3_rJt"}`
+
+// OKPPrivateKey is the Ed25519 key from RFC 8037 appendix A.1.
+var OKPPrivateKey = `{"kty":"OKP","crv":"Ed25519","d":"nWGxne_9WmC6hEr0kuwsxERJxWl7MmkZcDusAxyuf2A","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}`
+
+var OctKey = `{"kty":"oct","alg":"HS256","key_ops":["sign","verify"],"k":"c2VjcmV0"}`
+
+func TestNewKey(t *testing.T) {
+	k, err := NewKey([]byte("secret"))
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+	if k.KeyType != Oct {
+		t.Fatalf("expected %#q, got %#q", Oct, k.KeyType)
+	}
+}
+
+func TestNewKey_UnsupportedKeyType(t *testing.T) {
+	if _, err := NewKey("secret"); err != ErrUnsupportedKeyType {
+		t.Fatalf("expected %#q, got %#q", ErrUnsupportedKeyType, err)
+	}
+}
+
+func TestParse_RSAPrivateKey(t *testing.T) {
+	k, err := Parse([]byte(RSAPrivateKey))
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+	if k.KeyType != RSA {
+		t.Fatalf("expected %#q, got %#q", RSA, k.KeyType)
+	}
+	if k.KeyID != "MyKey" {
+		t.Fatalf("expected %#q, got %#q", "MyKey", k.KeyID)
+	}
+	if k.Use != "sig" {
+		t.Fatalf("expected %#q, got %#q", "sig", k.Use)
+	}
+	if _, ok := k.Key.(*rsa.PrivateKey); !ok {
+		t.Fatalf("expected *rsa.PrivateKey, got %T", k.Key)
+	}
+	if !k.IsPrivate() {
+		t.Fatal("expected true, got false")
+	}
+}
+
+func TestParse_RSAPublicKey(t *testing.T) {
+	k, err := Parse([]byte(RSAPublicKey))
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+	if _, ok := k.Key.(*rsa.PublicKey); !ok {
+		t.Fatalf("expected *rsa.PublicKey, got %T", k.Key)
+	}
+	if k.IsPrivate() {
+		t.Fatal("expected false, got true")
+	}
+}
+
+func TestParse_RSAPrivateKeyWithoutPrimes(t *testing.T) {
+	var m map[string]interface{}
+	if err := json.Unmarshal([]byte(RSAPrivateKey), &m); err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+	for _, name := range []string{"p", "q", "dp", "dq", "qi"} {
+		delete(m, name)
+	}
+	str, _ := json.Marshal(m)
+
+	k, err := Parse(str)
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+	key, ok := k.Key.(*rsa.PrivateKey)
+	if !ok {
+		t.Fatalf("expected *rsa.PrivateKey, got %T", k.Key)
+	}
+
+	hash := sha256.Sum256([]byte("payload"))
+	sig, err := rsa.SignPKCS1v15(nil, key, crypto.SHA256, hash[:])
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+	if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, hash[:], sig); err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+
+	b, err := json.Marshal(k)
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+	if !equalJSON(t, string(str), string(b)) {
+		t.Fatalf("expected %s, got %s", str, b)
+	}
+
+	m["d"] = m["e"]
+	str, _ = json.Marshal(m)
+	if _, err := Parse(str); err != ErrInvalidKey {
+		t.Fatalf("expected %#q, got %#q", ErrInvalidKey, err)
+	}
+}
+
+func TestParse_RSAInvalidPublicKey(t *testing.T) {
+	var m map[string]interface{}
+	if err := json.Unmarshal([]byte(RSAPublicKey), &m); err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+	n := m["n"]
+
+	for _, members := range [][2]interface{}{
+		{"AA", "AQAB"},
+		{"AQAB", "AQAB"},
+		{n, "AA"},
+		{n, "AQ"},
+		{n, "BA"},
+	} {
+		m["n"], m["e"] = members[0], members[1]
+		str, _ := json.Marshal(m)
+		if _, err := Parse(str); err != ErrInvalidKey {
+			t.Fatalf("expected %#q, got %#q", ErrInvalidKey, err)
+		}
+	}
+}
+
+func TestParse_RSAInvalidPrivateExponent(t *testing.T) {
+	if _, err := Parse([]byte(`{"kty":"RSA","n":"AA","e":"f____w","d":"AQ"}`)); err != ErrInvalidKey {
+		t.Fatalf("expected %#q, got %#q", ErrInvalidKey, err)
+	}
+
+	var m map[string]interface{}
+	if err := json.Unmarshal([]byte(RSAPublicKey), &m); err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+	for _, d := range []interface{}{"AA", m["n"]} {
+		m["d"] = d
+		str, _ := json.Marshal(m)
+		if _, err := Parse(str); err != ErrInvalidKey {
+			t.Fatalf("expected %#q, got %#q", ErrInvalidKey, err)
+		}
+	}
+}
+
+func TestParse_RSAMissingModulus(t *testing.T) {
+	if _, err := Parse([]byte(`{"kty":"RSA","e":"AQAB"}`)); err != ErrInvalidKey {
+		t.Fatalf("expected %#q, got %#q", ErrInvalidKey, err)
+	}
+}
+
+func TestParse_ECPrivateKey(t *testing.T) {
+	k, err := Parse([]byte(ECPrivateKey))
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+	if k.KeyType != EC {
+		t.Fatalf("expected %#q, got %#q", EC, k.KeyType)
+	}
+	if _, ok := k.Key.(*ecdsa.PrivateKey); !ok {
+		t.Fatalf("expected *ecdsa.PrivateKey, got %T", k.Key)
+	}
+}
+
+func TestParse_ECInvalidPoint(t *testing.T) {
+	str := `{"kty":"EC","crv":"P-256","x":"AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA","y":"AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAE"}`
+	if _, err := Parse([]byte(str)); err != ErrInvalidKey {
+		t.Fatalf("expected %#q, got %#q", ErrInvalidKey, err)
+	}
+}
+
+func TestParse_ECUnsupportedCurve(t *testing.T) {
+	str := `{"kty":"EC","crv":"P-192","x":"AA","y":"AA"}`
+	if _, err := Parse([]byte(str)); err != ErrUnsupportedCurve {
+		t.Fatalf("expected %#q, got %#q", ErrUnsupportedCurve, err)
+	}
+}
+
+func TestParse_OKPPrivateKey(t *testing.T) {
+	k, err := Parse([]byte(OKPPrivateKey))
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+	if _, ok := k.Key.(ed25519.PrivateKey); !ok {
+		t.Fatalf("expected ed25519.PrivateKey, got %T", k.Key)
+	}
+}
+
+func TestParse_OKPMismatchedKey(t *testing.T) {
+	str := `{"kty":"OKP","crv":"Ed25519","d":"nWGxne_9WmC6hEr0kuwsxERJxWl7MmkZcDusAxyuf2A","x":"AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"}`
+	if _, err := Parse([]byte(str)); err != ErrInvalidKey {
+		t.Fatalf("expected %#q, got %#q", ErrInvalidKey, err)
+	}
+}
+
+func TestParse_Oct(t *testing.T) {
+	k, err := Parse([]byte(OctKey))
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+	if string(k.Key.([]byte)) != "secret" {
+		t.Fatalf("expected %#q, got %#q", "secret", k.Key)
+	}
+	if k.Algorithm != "HS256" {
+		t.Fatalf("expected %#q, got %#q", "HS256", k.Algorithm)
+	}
+	if len(k.KeyOps) != 2 {
+		t.Fatalf("expected %d, got %d", 2, len(k.KeyOps))
+	}
+}
+
+func TestParse_UnsupportedKeyType(t *testing.T) {
+	if _, err := Parse([]byte(`{"kty":"INVALID"}`)); err != ErrUnsupportedKeyType {
+		t.Fatalf("expected %#q, got %#q", ErrUnsupportedKeyType, err)
+	}
+}
+
+func TestParse_InvalidJSON(t *testing.T) {
+	if _, err := Parse([]byte(`{"kty":`)); err == nil {
+		t.Fatal("expected non-nil, got nil")
+	}
+}
+
+func TestKeyMarshalJSON(t *testing.T) {
+	for _, str := range []string{RSAPrivateKey, RSAPublicKey, ECPrivateKey, OKPPrivateKey, OctKey} {
+		k, err := Parse([]byte(str))
+		if err != nil {
+			t.Fatalf("expected nil, got %#q", err)
+		}
+		b, err := json.Marshal(k)
+		if err != nil {
+			t.Fatalf("expected nil, got %#q", err)
+		}
+		if !equalJSON(t, str, string(b)) {
+			t.Fatalf("expected %s, got %s", str, b)
+		}
+	}
+}
+
+func TestKeyMarshalJSON_RSANotPrecomputed(t *testing.T) {
+	k, err := Parse([]byte(RSAPrivateKey))
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+	key := k.Key.(*rsa.PrivateKey)
+	k.Key = &rsa.PrivateKey{
+		PublicKey: key.PublicKey,
+		D:         key.D,
+		Primes:    key.Primes,
+	}
+
+	b, err := json.Marshal(k)
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+	if !equalJSON(t, RSAPrivateKey, string(b)) {
+		t.Fatalf("expected %s, got %s", RSAPrivateKey, b)
+	}
+	if k.Key.(*rsa.PrivateKey).Precomputed.Dp != nil {
+		t.Fatal("expected key to be unmodified")
+	}
+}
+
+func TestKeyMarshalJSON_UnsupportedKeyType(t *testing.T) {
+	if _, err := json.Marshal(&Key{Key: "secret"}); err == nil {
+		t.Fatal("expected non-nil, got nil")
+	}
+}
+
+func TestKeyPublic(t *testing.T) {
+	for _, str := range []string{RSAPrivateKey, ECPrivateKey, OKPPrivateKey} {
+		k, err := Parse([]byte(str))
+		if err != nil {
+			t.Fatalf("expected nil, got %#q", err)
+		}
+		pub := k.Public()
+		if pub.IsPrivate() {
+			t.Fatalf("expected public key, got %T", pub.Key)
+		}
+		if pub.KeyID != k.KeyID {
+			t.Fatalf("expected %#q, got %#q", k.KeyID, pub.KeyID)
+		}
+	}
+}
+
+// equalJSON compares two JSON documents regardless of member order.
+func equalJSON(t *testing.T, a, b string) bool {
+	var x, y map[string]interface{}
+	if err := json.Unmarshal([]byte(a), &x); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(b), &y); err != nil {
+		t.Fatal(err)
+	}
+	xb, _ := json.Marshal(x)
+	yb, _ := json.Marshal(y)
+	return string(xb) == string(yb)
+}
+
+func TestKeyThumbprint_RFC7638(t *testing.T) {
+	// The example key from RFC 7638 section 3.1.
+	k, err := Parse([]byte(`{"kty":"RSA","n":"0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw","e":"AQAB","alg":"RS256","kid":"2011-04-29"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := k.Thumbprint(crypto.SHA256)
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+	expected := "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs"
+	if v := base64.RawURLEncoding.EncodeToString(b); v != expected {
+		t.Fatalf("expected %#q, got %#q", expected, v)
+	}
+}
+
+func TestKeyThumbprint_PrivateKey(t *testing.T) {
+	for _, data := range []string{RSAPrivateKey, ECPrivateKey, OKPPrivateKey} {
+		k, err := Parse([]byte(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		private, err := k.Thumbprint(crypto.SHA256)
+		if err != nil {
+			t.Fatalf("expected nil, got %#q", err)
+		}
+		public, err := k.Public().Thumbprint(crypto.SHA256)
+		if err != nil {
+			t.Fatalf("expected nil, got %#q", err)
+		}
+		if !bytes.Equal(private, public) {
+			t.Fatalf("expected %x, got %x", public, private)
+		}
+	}
+}
+
+func TestKeyThumbprint_OKP(t *testing.T) {
+	// The example key from RFC 8037 appendix A.3.
+	k, err := Parse([]byte(`{"kty":"OKP","crv":"Ed25519","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := k.Thumbprint(crypto.SHA256)
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+	expected := "kPrK_qmxVWaYVA9wwBF6Iuo3vVzz7TxHCTwXBygrS4k"
+	if v := base64.RawURLEncoding.EncodeToString(b); v != expected {
+		t.Fatalf("expected %#q, got %#q", expected, v)
+	}
+}
+
+func TestKeyThumbprint_Oct(t *testing.T) {
+	k, err := NewKey([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := k.Thumbprint(crypto.SHA256)
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+	sum := sha256.Sum256([]byte(`{"k":"c2VjcmV0","kty":"oct"}`))
+	if !bytes.Equal(b, sum[:]) {
+		t.Fatalf("expected %x, got %x", sum, b)
+	}
+}
+
+func TestKeyThumbprint_UnsupportedHash(t *testing.T) {
+	k, err := NewKey([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := k.Thumbprint(crypto.Hash(0)); err != ErrUnsupportedHash {
+		t.Fatalf("expected %#q, got %#q", ErrUnsupportedHash, err)
+	}
+}
@@ -0,0 +1,363 @@
+package jwk
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultRefreshInterval is used when the response has no caching headers.
+	DefaultRefreshInterval = 1 * time.Hour
+
+	// DefaultMinRefreshInterval is the default minimum time between fetches.
+	DefaultMinRefreshInterval = 5 * time.Minute
+
+	// DefaultTimeout is the timeout of the client used when none is set.
+	DefaultTimeout = 30 * time.Second
+
+	// MaxKeySetSize is the maximum size of a key set response in bytes.
+	MaxKeySetSize = 1 << 20
+)
+
+var (
+	// ErrUnexpectedStatus is returned when the key set could not be fetched
+	// because the server responded with a non-200 status code.
+	ErrUnexpectedStatus = errors.New("jwt/jwk: unexpected response status")
+
+	// ErrKeySetTooLarge is returned when the key set response is larger than
+	// MaxKeySetSize.
+	ErrKeySetTooLarge = errors.New("jwt/jwk: key set too large")
+)
+
+// defaultClient is used to fetch key sets when no client is set. Unlike
+// http.DefaultClient it has a timeout, so an unresponsive server cannot block
+// lookups indefinitely.
+var defaultClient = &http.Client{Timeout: DefaultTimeout}
+
+// RemoteSet fetches a JSON Web Key Set over HTTP and keeps it up to date.
+//
+// The set is cached for as long as the Cache-Control or Expires response
+// headers allow, and is fetched again when it expires or when a key ID that
+// is not in the set is looked up. Fetches are never made more often than
+// MinRefreshInterval.
+//
+// RemoteSet implements Source and can be used with jwt.KeySourceFunc.
+type RemoteSet struct {
+	// URL is the location of the key set.
+	URL string
+
+	// Client is used to fetch the key set. If nil, a client with a timeout of
+	// DefaultTimeout is used. A custom client should also have a timeout,
+	// since lookups wait for the fetch to finish.
+	Client *http.Client
+
+	// RefreshInterval is how long the key set is cached when the response has
+	// no caching headers.
+	RefreshInterval time.Duration
+
+	// MinRefreshInterval is the minimum time between two fetches.
+	MinRefreshInterval time.Duration
+
+	// OnError is called whenever fetching the key set fails.
+	OnError func(error)
+
+	// Now returns the current time, which is used for all cache and refresh
+	// decisions. If nil, time.Now is used.
+	Now func() time.Time
+
+	// after is used by tests to control the background refresh.
+	after func(time.Duration) <-chan time.Time
+
+	mu      sync.RWMutex
+	set     *Set
+	err     error
+	expires time.Time
+	fetched time.Time
+
+	fetchMu sync.Mutex
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// NewRemoteSet creates a new RemoteSet for the given URL using the default
+// refresh intervals. The key set is not fetched until it is first needed or
+// Refresh or Start is called.
+func NewRemoteSet(url string) *RemoteSet {
+	return &RemoteSet{
+		URL:                url,
+		RefreshInterval:    DefaultRefreshInterval,
+		MinRefreshInterval: DefaultMinRefreshInterval,
+	}
+}
+
+// Set returns the current key set, fetching it if it has not been fetched
+// yet or has expired.
+func (r *RemoteSet) Set() (*Set, error) {
+	r.mu.RLock()
+	set, expires := r.set, r.expires
+	r.mu.RUnlock()
+
+	if set != nil && r.now().Before(expires) {
+		return set, nil
+	}
+
+	if err := r.refresh(false); err != nil {
+		// A stale key set is better than none while the server is unavailable.
+		if set != nil {
+			return set, nil
+		}
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.set, nil
+}
+
+// LookupKeyID returns the keys with the given key ID. If no key matches, the
+// key set is fetched again (subject to MinRefreshInterval) in case the key
+// has been rotated since the last fetch.
+func (r *RemoteSet) LookupKeyID(kid string) ([]*Key, error) {
+	set, err := r.Set()
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := set.LookupKeyID(kid)
+	if err != nil || len(keys) > 0 {
+		return keys, err
+	}
+
+	if err := r.refresh(false); err != nil {
+		return nil, nil
+	}
+
+	r.mu.RLock()
+	set = r.set
+	r.mu.RUnlock()
+
+	return set.LookupKeyID(kid)
+}
+
+// Refresh fetches the key set immediately, regardless of MinRefreshInterval.
+func (r *RemoteSet) Refresh() error {
+	return r.refresh(true)
+}
+
+// Start starts refreshing the key set in the background whenever it expires.
+// Calling Start on a RemoteSet that is already started has no effect.
+func (r *RemoteSet) Start() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.stop != nil {
+		return
+	}
+
+	r.stop = make(chan struct{})
+	r.done = make(chan struct{})
+
+	go r.run(r.stop, r.done)
+}
+
+// Stop stops refreshing the key set in the background and waits for the
+// background refresh to finish.
+func (r *RemoteSet) Stop() {
+	r.mu.Lock()
+	stop, done := r.stop, r.done
+	r.stop, r.done = nil, nil
+	r.mu.Unlock()
+
+	if stop == nil {
+		return
+	}
+
+	close(stop)
+	<-done
+}
+
+// run refreshes the key set whenever it expires until stop is closed.
+func (r *RemoteSet) run(stop, done chan struct{}) {
+	defer close(done)
+
+	for {
+		wait := r.minRefreshInterval()
+		if err := r.refresh(false); err == nil {
+			r.mu.RLock()
+			wait = r.expires.Sub(r.now())
+			r.mu.RUnlock()
+		}
+
+		if wait < r.minRefreshInterval() {
+			wait = r.minRefreshInterval()
+		}
+
+		c, cancel := r.timer(wait)
+		select {
+		case <-stop:
+			cancel()
+			return
+
+		case <-c:
+		}
+	}
+}
+
+// refresh fetches the key set. Unless forced, the fetch is skipped if the
+// last fetch was less than MinRefreshInterval ago.
+func (r *RemoteSet) refresh(force bool) error {
+	r.fetchMu.Lock()
+	defer r.fetchMu.Unlock()
+
+	r.mu.RLock()
+	fetched := r.fetched
+	r.mu.RUnlock()
+
+	if !force && !fetched.IsZero() && r.now().Sub(fetched) < r.minRefreshInterval() {
+		r.mu.RLock()
+		defer r.mu.RUnlock()
+		if r.set == nil {
+			return r.err
+		}
+		return nil
+	}
+
+	set, expires, err := r.fetch()
+
+	r.mu.Lock()
+	r.fetched = r.now()
+	r.err = err
+	if err == nil {
+		r.set = set
+		r.expires = expires
+	}
+	r.mu.Unlock()
+
+	if err != nil && r.OnError != nil {
+		r.OnError(err)
+	}
+
+	return err
+}
+
+// fetch requests the key set and determines when it expires.
+func (r *RemoteSet) fetch() (*Set, time.Time, error) {
+	client := r.Client
+	if client == nil {
+		client = defaultClient
+	}
+
+	res, err := client.Get(r.URL)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, time.Time{}, ErrUnexpectedStatus
+	}
+
+	b, err := io.ReadAll(io.LimitReader(res.Body, MaxKeySetSize+1))
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if len(b) > MaxKeySetSize {
+		return nil, time.Time{}, ErrKeySetTooLarge
+	}
+
+	set, err := ParseSet(b)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	return set, r.now().Add(r.cacheDuration(res.Header)), nil
+}
+
+// cacheDuration determines how long a response may be cached from its
+// Cache-Control and Expires headers.
+func (r *RemoteSet) cacheDuration(h http.Header) time.Duration {
+	d := r.RefreshInterval
+	if d <= 0 {
+		d = DefaultRefreshInterval
+	}
+
+	if cc := h.Get("Cache-Control"); len(cc) > 0 {
+		for _, directive := range strings.Split(cc, ",") {
+			directive = strings.ToLower(strings.TrimSpace(directive))
+
+			switch {
+			case directive == "no-cache" || directive == "no-store":
+				return r.minRefreshInterval()
+
+			case strings.HasPrefix(directive, "max-age="):
+				if s, err := strconv.Atoi(directive[len("max-age="):]); err == nil {
+					d = time.Duration(s) * time.Second
+					if age, err := strconv.Atoi(h.Get("Age")); err == nil {
+						d -= time.Duration(age) * time.Second
+					}
+					return r.clamp(d)
+				}
+			}
+		}
+	}
+
+	if v := h.Get("Expires"); len(v) > 0 {
+		expires, err := http.ParseTime(v)
+		if err != nil {
+			return r.minRefreshInterval()
+		}
+
+		now := r.now()
+		if date, err := http.ParseTime(h.Get("Date")); err == nil {
+			now = date
+		}
+
+		return r.clamp(expires.Sub(now))
+	}
+
+	return d
+}
+
+// clamp makes sure the cache duration is no shorter than MinRefreshInterval.
+func (r *RemoteSet) clamp(d time.Duration) time.Duration {
+	if minimum := r.minRefreshInterval(); d < minimum {
+		return minimum
+	}
+
+	return d
+}
+
+// now returns the current time using Now.
+func (r *RemoteSet) now() time.Time {
+	if r.Now == nil {
+		return time.Now()
+	}
+
+	return r.Now()
+}
+
+// timer returns a channel that receives once the duration has passed, along
+// with a function to stop the timer.
+func (r *RemoteSet) timer(d time.Duration) (<-chan time.Time, func()) {
+	if r.after != nil {
+		return r.after(d), func() {}
+	}
+
+	t := time.NewTimer(d)
+	return t.C, func() { t.Stop() }
+}
+
+// minRefreshInterval returns MinRefreshInterval or its default.
+func (r *RemoteSet) minRefreshInterval() time.Duration {
+	if r.MinRefreshInterval <= 0 {
+		return DefaultMinRefreshInterval
+	}
+
+	return r.MinRefreshInterval
+}
@@ -0,0 +1,342 @@
+package jwk
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var RemoteKeySet = `{"keys":[{"kty":"oct","kid":"MyKey","k":"c2VjcmV0"}]}`
+var RotatedKeySet = `{"keys":[{"kty":"oct","kid":"MyKey","k":"c2VjcmV0"},{"kty":"oct","kid":"MyNewKey","k":"c2VjcmV0"}]}`
+
+// testClock is a clock that only moves when told to.
+type testClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newTestClock() *testClock {
+	return &testClock{now: time.Unix(1424776307, 0)}
+}
+
+func (c *testClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *testClock) Add(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// keySetServer serves body with the given headers and counts requests.
+func keySetServer(body *atomic.Value, status int, headers map[string]string, requests *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+		for k, v := range headers {
+			w.Header().Set(k, v)
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(body.Load().(string)))
+	}))
+}
+
+func TestNewRemoteSet(t *testing.T) {
+	r := NewRemoteSet("http://localhost")
+	if r.RefreshInterval != DefaultRefreshInterval {
+		t.Fatalf("expected %s, got %s", DefaultRefreshInterval, r.RefreshInterval)
+	}
+	if r.MinRefreshInterval != DefaultMinRefreshInterval {
+		t.Fatalf("expected %s, got %s", DefaultMinRefreshInterval, r.MinRefreshInterval)
+	}
+}
+
+func TestRemoteSetLookupKeyID(t *testing.T) {
+	var body atomic.Value
+	var requests int32
+	body.Store(RemoteKeySet)
+	srv := keySetServer(&body, http.StatusOK, nil, &requests)
+	defer srv.Close()
+
+	r := NewRemoteSet(srv.URL)
+	for i := 0; i < 3; i++ {
+		keys, err := r.LookupKeyID("MyKey")
+		if err != nil {
+			t.Fatalf("expected nil, got %#q", err)
+		}
+		if len(keys) != 1 {
+			t.Fatalf("expected %d, got %d", 1, len(keys))
+		}
+	}
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Fatalf("expected %d requests, got %d", 1, n)
+	}
+}
+
+func TestRemoteSetLookupKeyID_UnknownKeyRefetch(t *testing.T) {
+	var body atomic.Value
+	var requests int32
+	body.Store(RemoteKeySet)
+	srv := keySetServer(&body, http.StatusOK, nil, &requests)
+	defer srv.Close()
+
+	clock := newTestClock()
+	r := NewRemoteSet(srv.URL)
+	r.MinRefreshInterval = 50 * time.Millisecond
+	r.Now = clock.Now
+	if _, err := r.Set(); err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+
+	body.Store(RotatedKeySet)
+
+	// The refetch is rate limited until MinRefreshInterval has passed.
+	keys, err := r.LookupKeyID("MyNewKey")
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+	if len(keys) != 0 {
+		t.Fatalf("expected %d, got %d", 0, len(keys))
+	}
+
+	clock.Add(60 * time.Millisecond)
+
+	keys, err = r.LookupKeyID("MyNewKey")
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+	if len(keys) != 1 {
+		t.Fatalf("expected %d, got %d", 1, len(keys))
+	}
+	if n := atomic.LoadInt32(&requests); n != 2 {
+		t.Fatalf("expected %d requests, got %d", 2, n)
+	}
+}
+
+func TestRemoteSetLookupKeyID_UnexpectedStatus(t *testing.T) {
+	var body atomic.Value
+	var requests int32
+	body.Store(RemoteKeySet)
+	srv := keySetServer(&body, http.StatusInternalServerError, nil, &requests)
+	defer srv.Close()
+
+	var hookErr error
+	r := NewRemoteSet(srv.URL)
+	r.OnError = func(err error) {
+		hookErr = err
+	}
+	if _, err := r.LookupKeyID("MyKey"); err != ErrUnexpectedStatus {
+		t.Fatalf("expected %#q, got %#q", ErrUnexpectedStatus, err)
+	}
+	if hookErr != ErrUnexpectedStatus {
+		t.Fatalf("expected %#q, got %#q", ErrUnexpectedStatus, hookErr)
+	}
+
+	// Subsequent lookups are rate limited but still report the error.
+	if _, err := r.LookupKeyID("MyKey"); err != ErrUnexpectedStatus {
+		t.Fatalf("expected %#q, got %#q", ErrUnexpectedStatus, err)
+	}
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Fatalf("expected %d requests, got %d", 1, n)
+	}
+}
+
+func TestRemoteSetRefresh(t *testing.T) {
+	var body atomic.Value
+	var requests int32
+	body.Store(RemoteKeySet)
+	srv := keySetServer(&body, http.StatusOK, nil, &requests)
+	defer srv.Close()
+
+	r := NewRemoteSet(srv.URL)
+	for i := 0; i < 2; i++ {
+		if err := r.Refresh(); err != nil {
+			t.Fatalf("expected nil, got %#q", err)
+		}
+	}
+	if n := atomic.LoadInt32(&requests); n != 2 {
+		t.Fatalf("expected %d requests, got %d", 2, n)
+	}
+}
+
+func TestRemoteSetRefresh_InvalidKeySet(t *testing.T) {
+	var body atomic.Value
+	var requests int32
+	body.Store(`{"keys":`)
+	srv := keySetServer(&body, http.StatusOK, nil, &requests)
+	defer srv.Close()
+
+	r := NewRemoteSet(srv.URL)
+	if err := r.Refresh(); err == nil {
+		t.Fatal("expected non-nil, got nil")
+	}
+}
+
+func TestRemoteSetRefresh_KeySetTooLarge(t *testing.T) {
+	var body atomic.Value
+	var requests int32
+	body.Store(strings.Repeat(" ", MaxKeySetSize) + RemoteKeySet)
+	srv := keySetServer(&body, http.StatusOK, nil, &requests)
+	defer srv.Close()
+
+	r := NewRemoteSet(srv.URL)
+	if err := r.Refresh(); err != ErrKeySetTooLarge {
+		t.Fatalf("expected %#q, got %#q", ErrKeySetTooLarge, err)
+	}
+}
+
+func TestRemoteSetDefaultClient(t *testing.T) {
+	if defaultClient.Timeout != DefaultTimeout {
+		t.Fatalf("expected %s, got %s", DefaultTimeout, defaultClient.Timeout)
+	}
+}
+
+func TestRemoteSetSet_StaleOnError(t *testing.T) {
+	var body atomic.Value
+	var requests int32
+	body.Store(RemoteKeySet)
+	srv := keySetServer(&body, http.StatusOK, map[string]string{"Cache-Control": "max-age=0"}, &requests)
+	defer srv.Close()
+
+	clock := newTestClock()
+	r := NewRemoteSet(srv.URL)
+	r.MinRefreshInterval = time.Millisecond
+	r.Now = clock.Now
+	if _, err := r.Set(); err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+
+	srv.Close()
+	clock.Add(5 * time.Millisecond)
+
+	set, err := r.Set()
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+	if len(set.Keys) != 1 {
+		t.Fatalf("expected %d, got %d", 1, len(set.Keys))
+	}
+}
+
+// startTicking makes the background refresh of the RemoteSet wait for ticks
+// on the returned channel. The clock is advanced by each wait, so every tick
+// is a refresh that is not rate limited.
+func startTicking(r *RemoteSet) chan time.Time {
+	clock := newTestClock()
+	ticks := make(chan time.Time)
+	r.Now = clock.Now
+	r.after = func(d time.Duration) <-chan time.Time {
+		clock.Add(d)
+		return ticks
+	}
+	return ticks
+}
+
+func TestRemoteSetStart(t *testing.T) {
+	var body atomic.Value
+	var requests int32
+	body.Store(RemoteKeySet)
+	srv := keySetServer(&body, http.StatusOK, map[string]string{"Cache-Control": "no-cache"}, &requests)
+	defer srv.Close()
+
+	r := NewRemoteSet(srv.URL)
+	r.MinRefreshInterval = 10 * time.Millisecond
+	ticks := startTicking(r)
+	r.Start()
+	r.Start()
+
+	// Each tick is only received once the previous refresh has finished.
+	ticks <- time.Time{}
+	ticks <- time.Time{}
+	r.Stop()
+	r.Stop()
+
+	if n := atomic.LoadInt32(&requests); n != 3 {
+		t.Fatalf("expected %d requests, got %d", 3, n)
+	}
+}
+
+func TestRemoteSetStart_OnError(t *testing.T) {
+	var body atomic.Value
+	var requests int32
+	body.Store(RemoteKeySet)
+	srv := keySetServer(&body, http.StatusNotFound, nil, &requests)
+	defer srv.Close()
+
+	var mu sync.Mutex
+	var errs []error
+	r := NewRemoteSet(srv.URL)
+	r.MinRefreshInterval = 10 * time.Millisecond
+	r.OnError = func(err error) {
+		mu.Lock()
+		errs = append(errs, err)
+		mu.Unlock()
+	}
+	ticks := startTicking(r)
+	r.Start()
+	ticks <- time.Time{}
+	r.Stop()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(errs) != 2 {
+		t.Fatalf("expected %d errors, got %d", 2, len(errs))
+	}
+	if errs[0] != ErrUnexpectedStatus {
+		t.Fatalf("expected %#q, got %#q", ErrUnexpectedStatus, errs[0])
+	}
+}
+
+func TestRemoteSetSet_Clock(t *testing.T) {
+	var body atomic.Value
+	var requests int32
+	body.Store(RemoteKeySet)
+	srv := keySetServer(&body, http.StatusOK, map[string]string{"Cache-Control": "max-age=600"}, &requests)
+	defer srv.Close()
+
+	clock := newTestClock()
+	r := NewRemoteSet(srv.URL)
+	r.Now = clock.Now
+	for _, d := range []time.Duration{0, 9 * time.Minute, 2 * time.Minute} {
+		clock.Add(d)
+		if _, err := r.Set(); err != nil {
+			t.Fatalf("expected nil, got %#q", err)
+		}
+	}
+	if n := atomic.LoadInt32(&requests); n != 2 {
+		t.Fatalf("expected %d requests, got %d", 2, n)
+	}
+}
+
+func TestRemoteSetCacheDuration(t *testing.T) {
+	r := NewRemoteSet("http://localhost")
+	r.MinRefreshInterval = time.Minute
+	now := time.Now().UTC()
+	tests := []struct {
+		headers  map[string]string
+		expected time.Duration
+	}{
+		{nil, DefaultRefreshInterval},
+		{map[string]string{"Cache-Control": "public, max-age=600"}, 10 * time.Minute},
+		{map[string]string{"Cache-Control": "max-age=600", "Age": "300"}, 5 * time.Minute},
+		{map[string]string{"Cache-Control": "max-age=5"}, time.Minute},
+		{map[string]string{"Cache-Control": "no-store"}, time.Minute},
+		{map[string]string{"Expires": now.Add(2 * time.Hour).Format(http.TimeFormat), "Date": now.Format(http.TimeFormat)}, 2 * time.Hour},
+		{map[string]string{"Expires": "0"}, time.Minute},
+	}
+	for _, test := range tests {
+		h := make(http.Header)
+		for k, v := range test.headers {
+			h.Set(k, v)
+		}
+		if d := r.cacheDuration(h); d != test.expected {
+			t.Fatalf("%v: expected %s, got %s", test.headers, test.expected, d)
+		}
+	}
+}
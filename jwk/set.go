@@ -0,0 +1,68 @@
+package jwk
+
+import "encoding/json"
+
+// Source is implemented by types that can look up keys by their key ID.
+type Source interface {
+	// LookupKeyID returns the keys with the given key ID.
+	LookupKeyID(kid string) ([]*Key, error)
+}
+
+// Set contains a JSON Web Key Set.
+type Set struct {
+	Keys []*Key `json:"keys"`
+}
+
+// ParseSet parses a JSON Web Key Set.
+//
+// Keys with an unsupported key type or curve are ignored, as recommended by
+// RFC 7517 section 5.
+func ParseSet(data []byte) (*Set, error) {
+	s := new(Set)
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// UnmarshalJSON decodes a JSON Web Key Set.
+func (s *Set) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Keys []json.RawMessage `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if raw.Keys == nil {
+		return ErrInvalidKey
+	}
+
+	keys := make([]*Key, 0, len(raw.Keys))
+	for _, b := range raw.Keys {
+		k := new(Key)
+		if err := k.UnmarshalJSON(b); err != nil {
+			if err == ErrUnsupportedKeyType || err == ErrUnsupportedCurve {
+				continue
+			}
+			return err
+		}
+		keys = append(keys, k)
+	}
+
+	s.Keys = keys
+
+	return nil
+}
+
+// LookupKeyID returns the keys in the set with the given key ID.
+func (s *Set) LookupKeyID(kid string) ([]*Key, error) {
+	var keys []*Key
+	for _, k := range s.Keys {
+		if k.KeyID == kid {
+			keys = append(keys, k)
+		}
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,78 @@
+package jwk
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+var KeySet = `{"keys":[` + RSAPublicKey + `,` + ECPrivateKey + `,{"kty":"UNKNOWN","kid":"MyKey"},{"kty":"EC","crv":"P-192","x":"AA","y":"AA"}]}`
+
+func TestParseSet(t *testing.T) {
+	s, err := ParseSet([]byte(KeySet))
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+	if len(s.Keys) != 2 {
+		t.Fatalf("expected %d, got %d", 2, len(s.Keys))
+	}
+}
+
+func TestParseSet_InvalidKey(t *testing.T) {
+	if _, err := ParseSet([]byte(`{"keys":[{"kty":"RSA","e":"AQAB"}]}`)); err != ErrInvalidKey {
+		t.Fatalf("expected %#q, got %#q", ErrInvalidKey, err)
+	}
+}
+
+func TestParseSet_MissingKeys(t *testing.T) {
+	if _, err := ParseSet([]byte(`{}`)); err != ErrInvalidKey {
+		t.Fatalf("expected %#q, got %#q", ErrInvalidKey, err)
+	}
+}
+
+func TestParseSet_InvalidJSON(t *testing.T) {
+	if _, err := ParseSet([]byte(`{"keys":`)); err == nil {
+		t.Fatal("expected non-nil, got nil")
+	}
+}
+
+func TestSetLookupKeyID(t *testing.T) {
+	s, err := ParseSet([]byte(KeySet))
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+	keys, err := s.LookupKeyID("bilbo.baggins@hobbiton.example")
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+	if len(keys) != 1 || keys[0].KeyType != EC {
+		t.Fatalf("expected one EC key, got %v", keys)
+	}
+}
+
+func TestSetLookupKeyID_NotFound(t *testing.T) {
+	s, err := ParseSet([]byte(KeySet))
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+	keys, err := s.LookupKeyID("MyOtherKey")
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+	if len(keys) != 0 {
+		t.Fatalf("expected %d, got %d", 0, len(keys))
+	}
+}
+
+func TestSetMarshalJSON(t *testing.T) {
+	s, err := ParseSet([]byte(KeySet))
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+	b, err := json.Marshal(s)
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+	if !equalJSON(t, `{"keys":[`+RSAPublicKey+`,`+ECPrivateKey+`]}`, string(b)) {
+		t.Fatalf("unexpected key set %s", b)
+	}
+}
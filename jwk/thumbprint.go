@@ -0,0 +1,60 @@
+package jwk
+
+import (
+	"crypto"
+	_ "crypto/sha256" // SHA-256 is the default thumbprint hash.
+	"encoding/json"
+	"errors"
+)
+
+// ErrUnsupportedHash is returned when the thumbprint hash function is not
+// available.
+var ErrUnsupportedHash = errors.New("jwt/jwk: unsupported hash function")
+
+// thumbprintMembers contains the required members of each key type, which
+// are the only members used to compute a thumbprint.
+var thumbprintMembers = map[KeyType][]string{
+	RSA: {"e", "kty", "n"},
+	EC:  {"crv", "kty", "x", "y"},
+	OKP: {"crv", "kty", "x"},
+	Oct: {"k", "kty"},
+}
+
+// Thumbprint computes the JWK thumbprint of the key using the given hash
+// function, as described in RFC 7638. The thumbprint of a private key is the
+// same as the thumbprint of its public key.
+//
+// SHA-256 is always available. Import the package of any other hash function,
+// such as crypto/sha512, to use it.
+func (k *Key) Thumbprint(h crypto.Hash) ([]byte, error) {
+	if !h.Available() {
+		return nil, ErrUnsupportedHash
+	}
+
+	b, err := json.Marshal(k.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	var members map[string]interface{}
+	if err := json.Unmarshal(b, &members); err != nil {
+		return nil, err
+	}
+
+	// The members are encoded in lexicographic order without whitespace,
+	// which is how encoding/json marshals a map.
+	required := make(map[string]interface{})
+	for _, name := range thumbprintMembers[KeyType(members["kty"].(string))] {
+		required[name] = members[name]
+	}
+
+	b, err = json.Marshal(required)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := h.New()
+	hash.Write(b)
+
+	return hash.Sum(nil), nil
+}
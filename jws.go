@@ -0,0 +1,285 @@
+package jwt
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+)
+
+// SigningKey contains the algorithm and key used to create one of the
+// signatures of a token in the JWS JSON serialization.
+type SigningKey struct {
+	// Algorithm is the algorithm used for the signature.
+	Algorithm Algorithm
+
+	// KeyID is added to the protected header as "kid" if not empty.
+	KeyID string
+
+	// Key is the secret used for the signature. Refer to the documentation
+	// in each encryption package for the supported types.
+	Key interface{}
+
+	// Header contains additional parameters for the unprotected header of
+	// the signature. They are not covered by the signature, so "crit" is not
+	// allowed.
+	Header map[string]interface{}
+}
+
+// jsonSignature contains a single signature in the JWS JSON serialization.
+type jsonSignature struct {
+	Protected string                 `json:"protected,omitempty"`
+	Header    map[string]interface{} `json:"header,omitempty"`
+	Signature string                 `json:"signature"`
+}
+
+// jsonGeneral is the general JWS JSON serialization.
+type jsonGeneral struct {
+	Payload    string          `json:"payload"`
+	Signatures []jsonSignature `json:"signatures"`
+}
+
+// jsonFlattened is the flattened JWS JSON serialization.
+type jsonFlattened struct {
+	Payload string `json:"payload"`
+	jsonSignature
+}
+
+// jsonToken is used to decode both the general and flattened JWS JSON
+// serializations.
+type jsonToken struct {
+	Payload    *string                `json:"payload"`
+	Signatures []jsonSignature        `json:"signatures"`
+	Protected  string                 `json:"protected"`
+	Header     map[string]interface{} `json:"header"`
+	Signature  *string                `json:"signature"`
+}
+
+// SignJSON signs the token with each of the given keys and returns the token
+// in the general JWS JSON serialization, as described in RFC 7515 section
+// 7.2.1. The Algorithm and KeyID fields of the token are not used.
+func (t Token) SignJSON(keys ...SigningKey) ([]byte, error) {
+	payload, signatures, err := t.signJSON(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(jsonGeneral{
+		Payload:    payload,
+		Signatures: signatures,
+	})
+}
+
+// SignFlattenedJSON signs the token with the given key and returns the token
+// in the flattened JWS JSON serialization, as described in RFC 7515 section
+// 7.2.2. The Algorithm and KeyID fields of the token are not used.
+func (t Token) SignFlattenedJSON(key SigningKey) ([]byte, error) {
+	payload, signatures, err := t.signJSON([]SigningKey{key})
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(jsonFlattened{
+		Payload:       payload,
+		jsonSignature: signatures[0],
+	})
+}
+
+// signJSON returns the encoded payload of the token along with a signature
+// for each of the keys.
+func (t Token) signJSON(keys []SigningKey) (string, []jsonSignature, error) {
+	if len(keys) == 0 {
+		return "", nil, ErrNoSigningKeys
+	}
+
+	claims, err := t.buildClaims()
+	if err != nil {
+		return "", nil, err
+	}
+
+	b, err := json.Marshal(claims)
+	if err != nil {
+		return "", nil, err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(b)
+
+	signatures := make([]jsonSignature, 0, len(keys))
+	for _, key := range keys {
+		// The protected header is built from the token, using the algorithm
+		// and key ID of the signing key.
+		kt := t
+		kt.Algorithm = key.Algorithm
+		kt.KeyID = key.KeyID
+		if err := kt.setThumbprintKeyID(key.Key); err != nil {
+			return "", nil, err
+		}
+
+		header, err := kt.buildHeader()
+		if err != nil {
+			return "", nil, err
+		}
+
+		// The "crit" parameter must be integrity protected, so it is not
+		// allowed in the unprotected header.
+		if _, ok := key.Header["crit"]; ok {
+			return "", nil, ErrInvalidToken
+		}
+		for k := range key.Header {
+			if _, ok := header[k]; ok {
+				return "", nil, ErrDuplicateHeader
+			}
+		}
+
+		b, err := json.Marshal(header)
+		if err != nil {
+			return "", nil, err
+		}
+		protected := base64.RawURLEncoding.EncodeToString(b)
+
+		signature, err := signInput(key.Algorithm, fmt.Sprintf("%s.%s", protected, payload), key.Key)
+		if err != nil {
+			return "", nil, err
+		}
+
+		signatures = append(signatures, jsonSignature{
+			Protected: protected,
+			Header:    key.Header,
+			Signature: signature,
+		})
+	}
+
+	return payload, signatures, nil
+}
+
+// DecodeJSON attempts to decode a token in the general or flattened JWS JSON
+// serialization. The key function is called with the protected and
+// unprotected header parameters of each signature.
+//
+// By default, the token is accepted if at least one of the signatures is
+// valid. Set RequireAllSignatures to require every signature to be valid.
+// The Type, Algorithm and KeyID fields of the token are taken from the first
+// valid signature. A signature whose "crit" header lists a parameter that is
+// not understood is treated as invalid.
+func (p *Parser) DecodeJSON(data []byte) (*Token, error) {
+	var raw jsonToken
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, newValidationError(KindMalformed, err)
+	}
+
+	signatures, err := raw.signatures()
+	if err != nil {
+		return nil, newValidationError(KindMalformed, err)
+	}
+
+	t := NewToken()
+	if err := decodePayload(t, *raw.Payload); err != nil {
+		return nil, newValidationError(KindMalformed, err)
+	}
+
+	headers := make([]Header, 0, len(signatures))
+	for _, sig := range signatures {
+		header, err := decodeJSONHeader(sig)
+		if err != nil {
+			return nil, newValidationError(KindMalformed, err)
+		}
+		if err := encodedPayload(header); err != nil {
+			return nil, newValidationError(KindMalformed, err)
+		}
+		if err := p.checkType(header); err != nil {
+			return nil, newValidationError(KindMalformed, err)
+		}
+		headers = append(headers, header)
+	}
+
+	var errs []error
+	verified := false
+	for i, sig := range signatures {
+		input := fmt.Sprintf("%s.%s", sig.Protected, *raw.Payload)
+		if err := p.verify(headers[i], input, sig.Signature); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := p.checkCritical(headers[i]); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		if !verified {
+			if err := readHeader(t, headers[i]); err != nil {
+				return nil, newValidationError(KindMalformed, err)
+			}
+			verified = true
+		}
+	}
+
+	if !verified || (p.RequireAllSignatures && len(errs) > 0) {
+		return nil, newValidationError(KindSignature, errs...)
+	}
+
+	if p.Validator != nil {
+		if err := p.Validator.Validate(t); err != nil {
+			return nil, err
+		}
+	}
+
+	return t, nil
+}
+
+// signatures returns the signatures of the token, making sure the token is
+// in either the general or the flattened serialization.
+func (raw jsonToken) signatures() ([]jsonSignature, error) {
+	if raw.Payload == nil {
+		return nil, ErrInvalidToken
+	}
+
+	if raw.Signatures != nil {
+		if len(raw.Signatures) == 0 || len(raw.Protected) > 0 || raw.Header != nil || raw.Signature != nil {
+			return nil, ErrInvalidToken
+		}
+		return raw.Signatures, nil
+	}
+
+	if raw.Signature == nil {
+		return nil, ErrInvalidToken
+	}
+
+	return []jsonSignature{
+		{
+			Protected: raw.Protected,
+			Header:    raw.Header,
+			Signature: *raw.Signature,
+		},
+	}, nil
+}
+
+// decodeJSONHeader decodes the protected header of a signature and merges it
+// with the unprotected header. The parameter names must be disjoint, and
+// "crit" must only be in the protected header.
+func decodeJSONHeader(sig jsonSignature) (Header, error) {
+	header := make(Header)
+	if len(sig.Protected) > 0 {
+		b, err := base64.RawURLEncoding.DecodeString(sig.Protected)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(b, &header); err != nil {
+			return nil, err
+		}
+		if header == nil {
+			return nil, ErrInvalidToken
+		}
+	}
+
+	// The "crit" parameter must be integrity protected.
+	if _, ok := sig.Header["crit"]; ok {
+		return nil, ErrInvalidToken
+	}
+
+	for k, v := range sig.Header {
+		if _, ok := header[k]; ok {
+			return nil, ErrDuplicateHeader
+		}
+		header[k] = v
+	}
+
+	return header, nil
+}
@@ -0,0 +1,300 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"gopkg.in/zhevron/jwt.v1/hmac"
+)
+
+// FlattenedRFC7515 is the flattened JWS JSON serialization example from RFC
+// 7515 appendix A.7.
+var FlattenedRFC7515 = `{
+	"payload": "eyJpc3MiOiJqb2UiLA0KICJleHAiOjEzMDA4MTkzODAsDQogImh0dHA6Ly9leGFtcGxlLmNvbS9pc19yb290Ijp0cnVlfQ",
+	"protected": "eyJhbGciOiJFUzI1NiJ9",
+	"header": {"kid": "e9bc097a-ce51-4036-9562-d2ade882db0d"},
+	"signature": "DtEhU3ljbEg8L38VWAfUAqOyKAM6-Xx-F4GawxaepmXFCgfTjDxw5djxLa8ISlSApmWQxfKTUJqPP3-Kg6NU1Q"
+}`
+
+var PublicKeyRFC7515 = `-----BEGIN PUBLIC KEY-----
+MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAEf83OJ3D2xF1Bg8vub9tLe1gHMzV7
+6e8Tus9uPHvRVEXH8UTNG72bfocs3+257rn0s2ldbqkLJK2KRiMohYjlrQ==
+-----END PUBLIC KEY-----`
+
+// jsonKeys returns a key function selecting keys by "kid" and the signing
+// keys for them.
+func jsonKeys(t *testing.T) (KeyFunc, []SigningKey) {
+	k, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyFunc := func(h Header) (Algorithm, interface{}, error) {
+		switch h.KeyID() {
+		case "MyHMACKey":
+			return HS256, "secret", nil
+
+		case "MyECKey":
+			return ES256, &k.PublicKey, nil
+		}
+
+		return "", nil, ErrNonExistantKey
+	}
+
+	return keyFunc, []SigningKey{
+		{Algorithm: HS256, KeyID: "MyHMACKey", Key: "secret"},
+		{Algorithm: ES256, KeyID: "MyECKey", Key: k},
+	}
+}
+
+func TestParserDecodeJSON_RFC7515(t *testing.T) {
+	p := NewParser(func(h Header) (Algorithm, interface{}, error) {
+		if h.KeyID() == "e9bc097a-ce51-4036-9562-d2ade882db0d" {
+			return ES256, PublicKeyRFC7515, nil
+		}
+		return "", nil, ErrNonExistantKey
+	})
+	tkn, err := p.DecodeJSON([]byte(FlattenedRFC7515))
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+	if tkn.Issuer != "joe" {
+		t.Fatalf("expected %#q, got %#q", "joe", tkn.Issuer)
+	}
+	if tkn.Algorithm != ES256 {
+		t.Fatalf("expected %#q, got %#q", ES256, tkn.Algorithm)
+	}
+	if tkn.Claims["http://example.com/is_root"] != true {
+		t.Fatalf("expected %v, got %v", true, tkn.Claims["http://example.com/is_root"])
+	}
+}
+
+func TestTokenSignJSON(t *testing.T) {
+	keyFunc, keys := jsonKeys(t)
+	tkn := NewToken()
+	tkn.Issuer = "MyIssuer"
+	tkn.Headers["cty"] = "example"
+	b, err := tkn.SignJSON(keys...)
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+
+	var raw jsonGeneral
+	if err := json.Unmarshal(b, &raw); err != nil {
+		t.Fatal(err)
+	}
+	if len(raw.Signatures) != 2 {
+		t.Fatalf("expected %d, got %d", 2, len(raw.Signatures))
+	}
+
+	p := NewParser(keyFunc)
+	p.RequireAllSignatures = true
+	tkn, err = p.DecodeJSON(b)
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+	if tkn.Issuer != "MyIssuer" {
+		t.Fatalf("expected %#q, got %#q", "MyIssuer", tkn.Issuer)
+	}
+	if tkn.KeyID != "MyHMACKey" {
+		t.Fatalf("expected %#q, got %#q", "MyHMACKey", tkn.KeyID)
+	}
+	if tkn.Headers["cty"] != "example" {
+		t.Fatalf("expected %#q, got %#q", "example", tkn.Headers["cty"])
+	}
+}
+
+func TestTokenSignJSON_NoKeys(t *testing.T) {
+	if _, err := NewToken().SignJSON(); err != ErrNoSigningKeys {
+		t.Fatalf("expected %#q, got %#q", ErrNoSigningKeys, err)
+	}
+}
+
+func TestTokenSignJSON_DuplicateHeader(t *testing.T) {
+	key := SigningKey{
+		Algorithm: HS256,
+		Key:       "secret",
+		Header:    map[string]interface{}{"alg": None},
+	}
+	if _, err := NewToken().SignJSON(key); err != ErrDuplicateHeader {
+		t.Fatalf("expected %#q, got %#q", ErrDuplicateHeader, err)
+	}
+}
+
+func TestTokenSignJSON_UnprotectedCritical(t *testing.T) {
+	key := SigningKey{
+		Algorithm: HS256,
+		Key:       "secret",
+		Header:    map[string]interface{}{"crit": []string{"exp"}, "exp": 1363284000},
+	}
+	if _, err := NewToken().SignJSON(key); err != ErrInvalidToken {
+		t.Fatalf("expected %#q, got %#q", ErrInvalidToken, err)
+	}
+
+	// The same parameters in the protected header round-trip.
+	tkn := NewToken()
+	tkn.Headers["crit"] = []string{"exp"}
+	tkn.Headers["exp"] = 1363284000
+	b, err := tkn.SignJSON(SigningKey{Algorithm: HS256, Key: "secret"})
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+
+	p := NewParser(staticKey(HS256, "secret"))
+	p.Critical = map[string]CriticalHandler{"exp": nil}
+	if _, err := p.DecodeJSON(b); err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+}
+
+func TestTokenSignFlattenedJSON(t *testing.T) {
+	key := SigningKey{
+		Algorithm: HS256,
+		Key:       "secret",
+		Header:    map[string]interface{}{"kid": "MyKey"},
+	}
+	b, err := NewToken().SignFlattenedJSON(key)
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+
+	var raw map[string]interface{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := raw["signatures"]; ok {
+		t.Fatalf("expected no %#q member", "signatures")
+	}
+
+	var header Header
+	p := NewParser(func(h Header) (Algorithm, interface{}, error) {
+		header = h
+		return HS256, "secret", nil
+	})
+	if _, err := p.DecodeJSON(b); err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+	if header.KeyID() != "MyKey" {
+		t.Fatalf("expected %#q, got %#q", "MyKey", header.KeyID())
+	}
+}
+
+func TestParserDecodeJSON_AnySignature(t *testing.T) {
+	keyFunc, keys := jsonKeys(t)
+	keys[0].Key = "other"
+	b, err := NewToken().SignJSON(keys...)
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+
+	tkn, err := NewParser(keyFunc).DecodeJSON(b)
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+	if tkn.KeyID != "MyECKey" {
+		t.Fatalf("expected %#q, got %#q", "MyECKey", tkn.KeyID)
+	}
+}
+
+func TestParserDecodeJSON_AllSignatures(t *testing.T) {
+	keyFunc, keys := jsonKeys(t)
+	keys[0].Key = "other"
+	b, err := NewToken().SignJSON(keys...)
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+
+	p := NewParser(keyFunc)
+	p.RequireAllSignatures = true
+	_, err = p.DecodeJSON(b)
+	if !errors.Is(err, ErrTokenSignatureInvalid) {
+		t.Fatalf("expected %#q, got %#q", ErrTokenSignatureInvalid, err)
+	}
+	if !errors.Is(err, hmac.ErrVerifyFailed) {
+		t.Fatalf("expected %#q, got %#q", hmac.ErrVerifyFailed, err)
+	}
+}
+
+func TestParserDecodeJSON_NoValidSignature(t *testing.T) {
+	_, keys := jsonKeys(t)
+	b, err := NewToken().SignJSON(keys[0])
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+
+	if _, err := NewParser(staticKey(HS256, "other")).DecodeJSON(b); !errors.Is(err, hmac.ErrVerifyFailed) {
+		t.Fatalf("expected %#q, got %#q", hmac.ErrVerifyFailed, err)
+	}
+}
+
+func TestParserDecodeJSON_DuplicateHeader(t *testing.T) {
+	str := `{"payload":"e30","protected":"eyJhbGciOiJIUzI1NiJ9","header":{"alg":"none"},"signature":""}`
+	_, err := NewParser(staticKey(HS256, "secret")).DecodeJSON([]byte(str))
+	if !errors.Is(err, ErrDuplicateHeader) {
+		t.Fatalf("expected %#q, got %#q", ErrDuplicateHeader, err)
+	}
+}
+
+func TestParserDecodeJSON_Malformed(t *testing.T) {
+	p := NewParser(staticKey(HS256, "secret"))
+	for _, str := range []string{
+		`INVALID`,
+		`{"protected":"eyJhbGciOiJIUzI1NiJ9","signature":""}`,
+		`{"payload":"e30","protected":"eyJhbGciOiJIUzI1NiJ9"}`,
+		`{"payload":"e30","signatures":[]}`,
+		`{"payload":"e30","signatures":[{"signature":""}],"signature":""}`,
+		`{"payload":"e30","protected":"!","signature":""}`,
+	} {
+		if _, err := p.DecodeJSON([]byte(str)); !errors.Is(err, ErrTokenMalformed) {
+			t.Fatalf("expected %#q, got %#q", ErrTokenMalformed, err)
+		}
+	}
+}
+
+func TestParserDecodeJSON_UnsupportedCritical(t *testing.T) {
+	tkn := NewToken()
+	tkn.Headers["crit"] = []string{"exp"}
+	tkn.Headers["exp"] = 1363284000
+	b, err := tkn.SignFlattenedJSON(SigningKey{Algorithm: HS256, Key: "secret"})
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+
+	p := NewParser(staticKey(HS256, "secret"))
+	if _, err := p.DecodeJSON(b); !errors.Is(err, ErrUnsupportedCritical) {
+		t.Fatalf("expected %#q, got %#q", ErrUnsupportedCritical, err)
+	}
+
+	p.Critical = map[string]CriticalHandler{"exp": nil}
+	if _, err := p.DecodeJSON(b); err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+}
+
+func TestParserDecodeJSON_UnprotectedCritical(t *testing.T) {
+	b, err := NewToken().SignFlattenedJSON(SigningKey{Algorithm: HS256, Key: "secret"})
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+
+	// The unprotected header is not covered by the signature, so it can be
+	// added after signing.
+	var m map[string]interface{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+	m["header"] = map[string]interface{}{"crit": []string{"exp"}, "exp": 1363284000}
+	if b, err = json.Marshal(m); err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+
+	p := NewParser(staticKey(HS256, "secret"))
+	p.Critical = map[string]CriticalHandler{"exp": nil}
+	if _, err := p.DecodeJSON(b); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("expected %#q, got %#q", ErrInvalidToken, err)
+	}
+}
@@ -3,18 +3,23 @@ package jwt
 
 import (
 	"errors"
+	"strconv"
+	"sync"
 
 	"gopkg.in/zhevron/jwt.v1/ecdsa"
+	"gopkg.in/zhevron/jwt.v1/eddsa"
 	"gopkg.in/zhevron/jwt.v1/hmac"
 	"gopkg.in/zhevron/jwt.v1/rsa"
 )
 
 // Signer is used by the signing packages to sign tokens.
-// You can use this type to implement your own signing and verification.
+// You can use this type to implement your own signing and verification,
+// and register it using RegisterAlgorithm.
 type Signer func(string, interface{}) (string, error)
 
 // Verifier is used by the signing packages to verify signatures.
-// You can use this type to implement your own signing and verification.
+// You can use this type to implement your own signing and verification,
+// and register it using RegisterAlgorithm.
 type Verifier func(string, string, interface{}) error
 
 // signingPair is used for internal mapping of signing/verifying functions.
@@ -29,6 +34,18 @@ type Type string
 const (
 	// JWT represents the JSON Web Token type.
 	JWT Type = "JWT"
+
+	// AccessToken represents an OAuth 2.0 access token, as defined by RFC 9068.
+	AccessToken Type = "at+jwt"
+
+	// LogoutToken represents an OpenID Connect back-channel logout token.
+	LogoutToken Type = "logout+jwt"
+
+	// DPoP represents a DPoP proof, as defined by RFC 9449.
+	DPoP Type = "dpop+jwt"
+
+	// SecurityEvent represents a Security Event Token, as defined by RFC 8417.
+	SecurityEvent Type = "secevent+jwt"
 )
 
 // Algorithm is used to define the encryption algorithm used for the token.
@@ -56,6 +73,15 @@ const (
 	// RS512 represents the RSA SHA-512 algorithm.
 	RS512 Algorithm = "RS512"
 
+	// PS256 represents the RSASSA-PSS SHA-256 algorithm.
+	PS256 Algorithm = "PS256"
+
+	// PS384 represents the RSASSA-PSS SHA-384 algorithm.
+	PS384 Algorithm = "PS384"
+
+	// PS512 represents the RSASSA-PSS SHA-512 algorithm.
+	PS512 Algorithm = "PS512"
+
 	// ES256 represents the ECDSA SHA-256 algorithm.
 	ES256 Algorithm = "ES256"
 
@@ -64,12 +90,33 @@ const (
 
 	// ES512 represents the ECDSA SHA-512 algorithm.
 	ES512 Algorithm = "ES512"
+
+	// EdDSA represents the Edwards-curve algorithm using Ed25519.
+	EdDSA Algorithm = "EdDSA"
 )
 
 var (
 	// ErrInvalidAlgorithm is returned when the algorithm is not set.
 	ErrInvalidAlgorithm = errors.New("jwt: invalid algorithm")
 
+	// ErrAlgorithmMismatch is returned when the "alg" header does not match the expected algorithm.
+	ErrAlgorithmMismatch = errors.New("jwt: algorithm mismatch")
+
+	// ErrAlgorithmNotAllowed is returned when the algorithm is not in the list of allowed algorithms.
+	ErrAlgorithmNotAllowed = errors.New("jwt: algorithm not allowed")
+
+	// ErrInvalidKeyType is returned when the key does not belong to the family of the algorithm.
+	ErrInvalidKeyType = errors.New("jwt: invalid key type for algorithm")
+
+	// ErrDuplicateHeader is returned when a header parameter is both protected and unprotected.
+	ErrDuplicateHeader = errors.New("jwt: duplicate header parameter")
+
+	// ErrInvalidCertificateChain is returned when the certificate chain of the token cannot be verified.
+	ErrInvalidCertificateChain = errors.New("jwt: invalid certificate chain")
+
+	// ErrNoCertificateRoots is returned when a certificate chain is verified without trusted roots.
+	ErrNoCertificateRoots = errors.New("jwt: no trusted certificate roots")
+
 	// ErrInvalidAudience is returned when the audience cannot be verified.
 	ErrInvalidAudience = errors.New("jwt: invalid audience")
 
@@ -85,24 +132,51 @@ var (
 	// ErrReservedClaim is returned when the user data contains a reserved claim.
 	ErrReservedClaim = errors.New("jwt: reserved claim used")
 
+	// ErrReservedHeader is returned when the custom headers contain a registered header set from the token fields.
+	ErrReservedHeader = errors.New("jwt: reserved header used")
+
 	// ErrTokenExpired is returned when the token has expired.
 	ErrTokenExpired = errors.New("jwt: token expired")
 
 	// ErrTokenNotValidYet is returned when the token is not valid yet.
 	ErrTokenNotValidYet = errors.New("jwt: token not valid yet")
 
+	// ErrTokenUsedBeforeIssued is returned when the token is issued in the future.
+	ErrTokenUsedBeforeIssued = errors.New("jwt: token used before issued")
+
+	// ErrTokenReplayed is returned when a token with the same ID has already been used.
+	ErrTokenReplayed = errors.New("jwt: token replayed")
+
+	// ErrMissingTokenID is returned when replay detection is enabled, but the token has no ID.
+	ErrMissingTokenID = errors.New("jwt: missing token id")
+
+	// ErrUnencodedPayload is returned when a token with an unencoded payload is not decoded as detached.
+	ErrUnencodedPayload = errors.New("jwt: unencoded payload must be detached")
+
 	// ErrUnsupportedAlgorithm is returned when the algorithm isn't implemented.
 	ErrUnsupportedAlgorithm = errors.New("jwt: unsupported algorithm")
 
+	// ErrUnsupportedCritical is returned when the "crit" header lists a parameter that is not understood.
+	ErrUnsupportedCritical = errors.New("jwt: unsupported critical header")
+
 	// ErrUnsupportedTokenType is returned when an unsupported token type is used.
 	ErrUnsupportedTokenType = errors.New("jwt: unsupported token type")
 
+	// ErrMissingTokenType is returned when a token type is required, but the token has no "typ" header.
+	ErrMissingTokenType = errors.New("jwt: missing token type")
+
+	// ErrNotNestedToken is returned when an encrypted token does not contain a signed JWT.
+	ErrNotNestedToken = errors.New("jwt: encrypted token is not a nested token")
+
 	// ErrNoneAlgorithmWithSecret is returned when the "none" algorithm is used with a secret.
 	ErrNoneAlgorithmWithSecret = errors.New("jwt: none algorithm with secret")
 
 	// ErrNoKeyProvided is returned when the key lookup callback is set, but no key is in the token.
 	ErrNoKeyProvided = errors.New("jwt: no key provided")
 
+	// ErrNoSigningKeys is returned when a token is signed without any keys.
+	ErrNoSigningKeys = errors.New("jwt: no signing keys")
+
 	// ErrNonExistantKey is returned when the provided key ID does not exist.
 	ErrNonExistantKey = errors.New("jwt: non-existant key")
 )
@@ -111,23 +185,28 @@ var (
 // if the "kid" header is specified in the token.
 var keyLookupCallback func(string) (Algorithm, interface{})
 
-// supportedTypes is used to determine if a token type is supported.
-var supportedTypes = map[Type]bool{
-	JWT: true,
-}
+// defaultTypes contains the token types accepted by a Parser without Types.
+var defaultTypes = []Type{JWT}
+
+// algorithmsMu protects supportedAlgorithms.
+var algorithmsMu sync.RWMutex
 
 // supportedAlgorithms is used to determine if an algorithm is supported.
+// The "none" algorithm is handled separately and is never in the map.
 var supportedAlgorithms = map[Algorithm]signingPair{
-	None:  {nil, nil},
 	HS256: {hmac.SignHS256, hmac.VerifyHS256},
 	HS384: {hmac.SignHS384, hmac.VerifyHS384},
 	HS512: {hmac.SignHS512, hmac.VerifyHS512},
 	RS256: {rsa.SignRS256, rsa.VerifyRS256},
 	RS384: {rsa.SignRS384, rsa.VerifyRS384},
 	RS512: {rsa.SignRS512, rsa.VerifyRS512},
+	PS256: {rsa.SignPS256, rsa.VerifyPS256},
+	PS384: {rsa.SignPS384, rsa.VerifyPS384},
+	PS512: {rsa.SignPS512, rsa.VerifyPS512},
 	ES256: {ecdsa.SignES256, ecdsa.VerifyES256},
 	ES384: {ecdsa.SignES384, ecdsa.VerifyES384},
 	ES512: {ecdsa.SignES512, ecdsa.VerifyES512},
+	EdDSA: {eddsa.SignEdDSA, eddsa.VerifyEdDSA},
 }
 
 // reservedClaims is used to make sure no reserved claims are used in user data.
@@ -141,6 +220,30 @@ var reservedClaims = map[string]bool{
 	"jti": true,
 }
 
+// reservedHeaders is used to make sure the custom headers do not override the
+// headers set from the token fields.
+var reservedHeaders = map[string]bool{
+	"typ": true,
+	"alg": true,
+	"kid": true,
+}
+
+// registeredHeaders contains the header parameters registered by RFC 7515,
+// which must not be listed in the "crit" header.
+var registeredHeaders = map[string]bool{
+	"alg":      true,
+	"jku":      true,
+	"jwk":      true,
+	"kid":      true,
+	"x5u":      true,
+	"x5c":      true,
+	"x5t":      true,
+	"x5t#S256": true,
+	"typ":      true,
+	"cty":      true,
+	"crit":     true,
+}
+
 // KeyLookupCallback sets the callback function to look up the algorithm and
 // secret to use for a given "kid" header (located in the token header).
 //
@@ -150,6 +253,50 @@ var reservedClaims = map[string]bool{
 //
 // If the callback returns "" for the Algorithm, the subsequent token validation
 // will fail with ErrNonExistantKey.
+//
+// Deprecated: The callback is shared by every caller of DecodeToken. Use a
+// Parser or DecodeTokenFunc with its own KeyFunc instead.
 func KeyLookupCallback(callback func(string) (Algorithm, interface{})) {
 	keyLookupCallback = callback
 }
+
+// RegisterAlgorithm registers the signer and verifier to use for the given
+// algorithm. It can be used to add new algorithms or to replace the built-in
+// implementation of an algorithm, such as signing RS256 tokens using an HSM.
+//
+// RegisterAlgorithm panics if the algorithm is empty or "none", or if the
+// signer or verifier is nil. It is safe for concurrent use.
+func RegisterAlgorithm(algorithm Algorithm, signer Signer, verifier Verifier) {
+	if len(algorithm) == 0 || algorithm == None {
+		panic("jwt: cannot register algorithm " + strconv.Quote(string(algorithm)))
+	}
+	if signer == nil || verifier == nil {
+		panic("jwt: nil signer or verifier for algorithm " + string(algorithm))
+	}
+
+	algorithmsMu.Lock()
+	defer algorithmsMu.Unlock()
+
+	supportedAlgorithms[algorithm] = signingPair{signer, verifier}
+}
+
+// UnregisterAlgorithm removes the given algorithm, so tokens using it can no
+// longer be signed or decoded. It is safe for concurrent use.
+func UnregisterAlgorithm(algorithm Algorithm) {
+	algorithmsMu.Lock()
+	defer algorithmsMu.Unlock()
+
+	delete(supportedAlgorithms, algorithm)
+}
+
+// LookupAlgorithm returns the signer and verifier registered for the given
+// algorithm. The last return value is false if the algorithm is not
+// registered. It is safe for concurrent use.
+func LookupAlgorithm(algorithm Algorithm) (Signer, Verifier, bool) {
+	algorithmsMu.RLock()
+	defer algorithmsMu.RUnlock()
+
+	pair, ok := supportedAlgorithms[algorithm]
+
+	return pair.Signer, pair.Verifier, ok
+}
@@ -0,0 +1,79 @@
+package jwt
+
+import "gopkg.in/zhevron/jwt.v1/jwk"
+
+// keyTypes is used to determine the key type required by an algorithm.
+var keyTypes = map[Algorithm]jwk.KeyType{
+	HS256: jwk.Oct,
+	HS384: jwk.Oct,
+	HS512: jwk.Oct,
+	RS256: jwk.RSA,
+	RS384: jwk.RSA,
+	RS512: jwk.RSA,
+	PS256: jwk.RSA,
+	PS384: jwk.RSA,
+	PS512: jwk.RSA,
+	ES256: jwk.EC,
+	ES384: jwk.EC,
+	ES512: jwk.EC,
+	EdDSA: jwk.OKP,
+}
+
+// KeySourceFunc returns a key function that selects the verification key from
+// the given source (such as a *jwk.Set or *jwk.RemoteSet) using the "kid"
+// header of the token.
+//
+// A key is only selected if its "use" and "key_ops" members permit signature
+// verification, its "alg" member (if set) matches the "alg" header and its key
+// type is suitable for that algorithm. Decoding fails with ErrNoKeyProvided if
+// the token has no "kid" header and with ErrNonExistantKey if no key matches.
+func KeySourceFunc(src jwk.Source) KeyFunc {
+	return func(h Header) (Algorithm, interface{}, error) {
+		kid := h.KeyID()
+		if len(kid) == 0 {
+			return "", nil, ErrNoKeyProvided
+		}
+
+		keys, err := src.LookupKeyID(kid)
+		if err != nil {
+			return "", nil, err
+		}
+
+		algorithm := h.Algorithm()
+		for _, k := range keys {
+			if verificationKey(algorithm, k) {
+				return algorithm, k, nil
+			}
+		}
+
+		return "", nil, ErrNonExistantKey
+	}
+}
+
+// verificationKey checks if the key may be used to verify a signature created
+// using the given algorithm.
+func verificationKey(algorithm Algorithm, k *jwk.Key) bool {
+	if len(k.Use) > 0 && k.Use != "sig" {
+		return false
+	}
+
+	if len(k.KeyOps) > 0 {
+		ok := false
+		for _, op := range k.KeyOps {
+			if op == "verify" {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+
+	if len(k.Algorithm) > 0 && Algorithm(k.Algorithm) != algorithm {
+		return false
+	}
+
+	kty, ok := keyTypes[algorithm]
+	return ok && kty == k.KeyType
+}
@@ -0,0 +1,118 @@
+package jwt
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gopkg.in/zhevron/jwt.v1/jwk"
+)
+
+var KeySet = `{"keys":[
+	{"kty":"oct","kid":"MyKey","k":"c2VjcmV0"},
+	{"kty":"oct","kid":"MyEncryptionKey","use":"enc","k":"c2VjcmV0"},
+	{"kty":"oct","kid":"MySigningKey","key_ops":["sign"],"k":"c2VjcmV0"},
+	{"kty":"oct","kid":"MyHS512Key","alg":"HS512","k":"c2VjcmV0"},
+	{"kty":"OKP","kid":"MyEdKey","crv":"Ed25519","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}
+]}`
+
+func signWithKeyID(t *testing.T, algorithm Algorithm, kid string, secret interface{}) string {
+	tkn := NewToken()
+	tkn.Algorithm = algorithm
+	tkn.KeyID = kid
+	tkn.Issuer = "MyIssuer"
+	str, err := tkn.Sign(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return str
+}
+
+func TestKeySourceFunc(t *testing.T) {
+	set, err := jwk.ParseSet([]byte(KeySet))
+	if err != nil {
+		t.Fatal(err)
+	}
+	str := signWithKeyID(t, HS256, "MyKey", "secret")
+	tkn, err := DecodeTokenFunc(str, KeySourceFunc(set))
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+	if tkn.Issuer != "MyIssuer" {
+		t.Fatalf("expected %#q, got %#q", "MyIssuer", tkn.Issuer)
+	}
+}
+
+func TestKeySourceFunc_NoKeyProvided(t *testing.T) {
+	set, err := jwk.ParseSet([]byte(KeySet))
+	if err != nil {
+		t.Fatal(err)
+	}
+	str := signWithKeyID(t, HS256, "", "secret")
+	if _, err := DecodeTokenFunc(str, KeySourceFunc(set)); !errors.Is(err, ErrNoKeyProvided) {
+		t.Fatalf("expected %#q, got %#q", ErrNoKeyProvided, err)
+	}
+}
+
+func TestKeySourceFunc_NonExistantKey(t *testing.T) {
+	set, err := jwk.ParseSet([]byte(KeySet))
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFunc := KeySourceFunc(set)
+	tests := []Header{
+		{"alg": "HS256", "kid": "MyOtherKey"},
+		{"alg": "HS256", "kid": "MyEncryptionKey"},
+		{"alg": "HS256", "kid": "MySigningKey"},
+		{"alg": "HS256", "kid": "MyHS512Key"},
+		{"alg": "RS256", "kid": "MyKey"},
+		{"alg": "HS256", "kid": "MyEdKey"},
+	}
+	for _, h := range tests {
+		if _, _, err := keyFunc(h); !errors.Is(err, ErrNonExistantKey) {
+			t.Fatalf("%v: expected %#q, got %#q", h, ErrNonExistantKey, err)
+		}
+	}
+}
+
+func TestKeySourceFunc_Algorithm(t *testing.T) {
+	set, err := jwk.ParseSet([]byte(KeySet))
+	if err != nil {
+		t.Fatal(err)
+	}
+	algorithm, key, err := KeySourceFunc(set)(Header{"alg": "HS512", "kid": "MyHS512Key"})
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+	if algorithm != HS512 {
+		t.Fatalf("expected %#q, got %#q", HS512, algorithm)
+	}
+	if key.(*jwk.Key).KeyID != "MyHS512Key" {
+		t.Fatalf("expected %#q, got %#q", "MyHS512Key", key.(*jwk.Key).KeyID)
+	}
+}
+
+func TestKeySourceFunc_NoneAlgorithm(t *testing.T) {
+	set, err := jwk.ParseSet([]byte(KeySet))
+	if err != nil {
+		t.Fatal(err)
+	}
+	str := signWithKeyID(t, None, "MyKey", nil)
+	if _, err := DecodeTokenFunc(str, KeySourceFunc(set)); !errors.Is(err, ErrNonExistantKey) {
+		t.Fatalf("expected %#q, got %#q", ErrNonExistantKey, err)
+	}
+}
+
+func TestKeySourceFunc_RemoteSet(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=3600")
+		w.Write([]byte(KeySet))
+	}))
+	defer srv.Close()
+
+	str := signWithKeyID(t, HS256, "MyKey", "secret")
+	if _, err := DecodeTokenFunc(str, KeySourceFunc(jwk.NewRemoteSet(srv.URL))); err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+}
@@ -0,0 +1,66 @@
+package jwt
+
+import (
+	"strings"
+
+	"gopkg.in/zhevron/jwt.v1/jwe"
+)
+
+// nestedContentType is the "cty" header of an encrypted token containing a
+// signed JWT, as defined by RFC 7519 section 5.2.
+const nestedContentType = "JWT"
+
+// SignEncrypted signs the token with the provided secret, and then encrypts
+// the signed token for the recipient as a nested JWT. The algorithms used for
+// encryption are taken from the header, and the "cty" header is always set to
+// "JWT".
+//
+// The key parameter type depends on the key algorithm of the header. Refer to
+// the documentation of jwe.Encrypt for the options.
+func (t Token) SignEncrypted(secret interface{}, header jwe.Header, key interface{}) (string, error) {
+	tkn, err := t.Sign(secret)
+	if err != nil {
+		return "", err
+	}
+
+	header.ContentType = nestedContentType
+
+	return jwe.Encrypt([]byte(tkn), header, key)
+}
+
+// DecodeEncryptedToken works like DecodeToken, but first decrypts the nested
+// JWT using the given key algorithm and decryption key. The decoded token is
+// then checked with Verify using the provided issuer, subject and audiences,
+// so an expired or not yet valid token is rejected.
+func DecodeEncryptedToken(token string, keyAlgorithm jwe.KeyAlgorithm, decryptionKey interface{}, algorithm Algorithm, secret interface{}, issuer, subject string, audience ...string) (*Token, error) {
+	t, err := defaultParser(algorithm, secret).DecodeEncrypted(token, keyAlgorithm, decryptionKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := t.Verify(issuer, subject, audience...); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// DecodeEncrypted decrypts a nested JWT using the given key algorithm and
+// key, and then decodes and verifies the signed token it contains exactly
+// like Decode. Set a Validator on the parser to also validate the claims.
+//
+// If the token cannot be decrypted, a ValidationError of kind KindDecryption
+// is returned. ErrNotNestedToken is returned if the "cty" header of the
+// encrypted token is not "JWT".
+func (p *Parser) DecodeEncrypted(token string, algorithm jwe.KeyAlgorithm, key interface{}) (*Token, error) {
+	plaintext, header, err := jwe.Decrypt(token, algorithm, key)
+	if err != nil {
+		return nil, newValidationError(KindDecryption, err)
+	}
+
+	if !strings.EqualFold(header.ContentType, nestedContentType) {
+		return nil, newValidationError(KindMalformed, ErrNotNestedToken)
+	}
+
+	return p.Decode(string(plaintext))
+}
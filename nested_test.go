@@ -0,0 +1,117 @@
+package jwt
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"gopkg.in/zhevron/jwt.v1/jwe"
+)
+
+var NestedKey = []byte("0123456789abcdef")
+
+func TestSignEncrypted(t *testing.T) {
+	tkn := NewToken()
+	tkn.Issuer = "MyIssuer"
+	tkn.Claims["username"] = "my_username"
+
+	str, err := tkn.SignEncrypted("secret", jwe.Header{Algorithm: jwe.A128KW, Encryption: jwe.A128GCM}, NestedKey)
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+
+	decoded, err := DecodeEncryptedToken(str, jwe.A128KW, NestedKey, HS256, "secret", "MyIssuer", "")
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+	if decoded.Issuer != "MyIssuer" {
+		t.Fatalf("expected %#q, got %#q", "MyIssuer", decoded.Issuer)
+	}
+	if decoded.Claims["username"] != "my_username" {
+		t.Fatalf("expected %#q, got %#q", "my_username", decoded.Claims["username"])
+	}
+}
+
+func TestDecodeEncryptedToken_Verify(t *testing.T) {
+	tkn := NewToken()
+	tkn.Issuer = "MyIssuer"
+	tkn.IssuedAt = time.Now().Add(-2 * time.Hour)
+	tkn.Expires = tkn.IssuedAt.Add(time.Hour)
+
+	str, err := tkn.SignEncrypted("secret", jwe.Header{Algorithm: jwe.A128KW, Encryption: jwe.A128GCM}, NestedKey)
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+
+	if _, err := DecodeEncryptedToken(str, jwe.A128KW, NestedKey, HS256, "secret", "", ""); !errors.Is(err, ErrTokenExpired) {
+		t.Fatalf("expected %#q, got %#q", ErrTokenExpired, err)
+	}
+
+	tkn.Expires = time.Now().Add(time.Hour)
+	str, err = tkn.SignEncrypted("secret", jwe.Header{Algorithm: jwe.A128KW, Encryption: jwe.A128GCM}, NestedKey)
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+
+	if _, err := DecodeEncryptedToken(str, jwe.A128KW, NestedKey, HS256, "secret", "OtherIssuer", ""); !errors.Is(err, ErrInvalidIssuer) {
+		t.Fatalf("expected %#q, got %#q", ErrInvalidIssuer, err)
+	}
+}
+
+func TestParserDecodeEncrypted_Validator(t *testing.T) {
+	tkn := NewToken()
+	tkn.Expires = tkn.IssuedAt.Add(time.Minute)
+
+	str, err := tkn.SignEncrypted("secret", jwe.Header{Algorithm: jwe.Direct, Encryption: jwe.A128GCM}, NestedKey)
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+
+	p := NewParser(staticKey(HS256, "secret"))
+	p.Validator = &Validator{
+		Clock: ClockFunc(func() time.Time { return tkn.Expires.Add(time.Hour) }),
+	}
+	if _, err := p.DecodeEncrypted(str, jwe.Direct, NestedKey); !errors.Is(err, ErrTokenExpired) {
+		t.Fatalf("expected %#q, got %#q", ErrTokenExpired, err)
+	}
+}
+
+func TestParserDecodeEncrypted_DecryptionFailed(t *testing.T) {
+	str, err := NewToken().SignEncrypted("secret", jwe.Header{Algorithm: jwe.A128KW, Encryption: jwe.A128GCM}, NestedKey)
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+
+	p := NewParser(staticKey(HS256, "secret"))
+	_, err = p.DecodeEncrypted(str, jwe.A128KW, []byte("fedcba9876543210"))
+	if !errors.Is(err, ErrTokenDecryptionFailed) {
+		t.Fatalf("expected %#q, got %#q", ErrTokenDecryptionFailed, err)
+	}
+	if !errors.Is(err, jwe.ErrDecryptFailed) {
+		t.Fatalf("expected %#q, got %#q", jwe.ErrDecryptFailed, err)
+	}
+}
+
+func TestParserDecodeEncrypted_SignatureInvalid(t *testing.T) {
+	str, err := NewToken().SignEncrypted("secret", jwe.Header{Algorithm: jwe.A128KW, Encryption: jwe.A128GCM}, NestedKey)
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+
+	p := NewParser(staticKey(HS256, "invalid"))
+	if _, err := p.DecodeEncrypted(str, jwe.A128KW, NestedKey); !errors.Is(err, ErrTokenSignatureInvalid) {
+		t.Fatalf("expected %#q, got %#q", ErrTokenSignatureInvalid, err)
+	}
+}
+
+func TestParserDecodeEncrypted_NotNested(t *testing.T) {
+	str, err := jwe.Encrypt([]byte("hello"), jwe.Header{Algorithm: jwe.A128KW, Encryption: jwe.A128GCM}, NestedKey)
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+
+	p := NewParser(staticKey(HS256, "secret"))
+	if _, err := p.DecodeEncrypted(str, jwe.A128KW, NestedKey); !errors.Is(err, ErrNotNestedToken) {
+		t.Fatalf("expected %#q, got %#q", ErrNotNestedToken, err)
+	}
+}
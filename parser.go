@@ -0,0 +1,450 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gopkg.in/zhevron/jwt.v1/jwk"
+)
+
+// Header contains the decoded JOSE header parameters of a token.
+type Header map[string]interface{}
+
+// KeyFunc is used by a Parser to look up the algorithm and key to verify a
+// token with, based on the decoded token header.
+//
+// Returning a non-nil error aborts decoding and the error is returned to the
+// caller wrapped in a ValidationError of kind KindSignature.
+type KeyFunc func(Header) (Algorithm, interface{}, error)
+
+// CriticalHandler is used by a Parser to process an extension header
+// parameter listed in the "crit" header. It is called with the decoded header
+// once the signature has been verified, and the token is rejected if it
+// returns an error.
+type CriticalHandler func(Header) error
+
+// Parser decodes and verifies tokens using its own key function, which makes
+// it possible to use several key sources in the same program.
+type Parser struct {
+	// KeyFunc is called with the decoded header of every token to look up the
+	// algorithm and key used for verification.
+	KeyFunc KeyFunc
+
+	// LegacyPadding allows segments padded with "=", as issued by earlier
+	// versions of this package. Enable this while migrating existing tokens;
+	// DecodeToken never accepts padded segments.
+	LegacyPadding bool
+
+	// Validator is used to validate the claims of every decoded token. If
+	// nil, the claims are not validated and Token.Verify should be used.
+	Validator *Validator
+
+	// Algorithms contains the algorithms tokens may be signed with. If empty,
+	// any supported algorithm returned by the key function is allowed.
+	Algorithms []Algorithm
+
+	// KeyAlgorithms contains the algorithms allowed for specific keys, by
+	// key ID. When the key function returns a *jwk.Key whose KeyID is in the
+	// map, it is used instead of Algorithms. The "kid" header of the token is
+	// never used to select the algorithms, since it is chosen by the sender.
+	KeyAlgorithms map[string][]Algorithm
+
+	// Types contains the token types accepted in the "typ" header. Types are
+	// compared case-insensitively, and the "application/" prefix is ignored
+	// as described in RFC 7515. If empty, only JWT is accepted.
+	Types []Type
+
+	// RequireType rejects tokens without a "typ" header. Combine it with
+	// Types to only accept a specific kind of token, such as AccessToken,
+	// which prevents other kinds of tokens signed with the same key from
+	// being accepted in its place.
+	RequireType bool
+
+	// Critical contains the extension header parameters the parser
+	// understands when they are listed in the "crit" header, along with the
+	// handler used to process each of them. The handler may be nil if no
+	// processing is needed. Tokens listing any other parameter are rejected
+	// with ErrUnsupportedCritical. The "b64" parameter from RFC 7797 is always
+	// understood.
+	Critical map[string]CriticalHandler
+
+	// RequireAllSignatures makes DecodeJSON require every signature of a
+	// token to be valid, instead of at least one.
+	RequireAllSignatures bool
+}
+
+// NewParser creates a new Parser using the given key function.
+func NewParser(keyFunc KeyFunc) *Parser {
+	return &Parser{
+		KeyFunc: keyFunc,
+	}
+}
+
+// Decode attempts to decode a JWT into a Token structure, using the algorithm
+// and key returned by the key function for verification. Unless a Validator
+// is set, this function will only return an error if decoding fails or the
+// signature is invalid.
+//
+// All errors are returned as a ValidationError, whose Kind tells whether the
+// token is malformed, its signature is invalid or its claims are invalid.
+//
+// Note: The "alg" field of the token header is never used to choose the
+// algorithm. It must match the algorithm returned by the key function, in
+// order to ensure that a token is what the server expects.
+func (p *Parser) Decode(token string) (*Token, error) {
+	t, _, err := p.decode(token)
+
+	return t, err
+}
+
+// DecodeClaims works like Decode, but also unmarshals the payload into
+// claims, which must be a pointer. Embed RegisteredClaims in your own struct
+// to decode the registered claims along with your custom claims.
+//
+// The claims are only decoded once the signature has been verified.
+func (p *Parser) DecodeClaims(token string, claims interface{}) (*Token, error) {
+	t, payload, err := p.decode(token)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, newValidationError(KindMalformed, err)
+	}
+
+	if err := json.Unmarshal(b, claims); err != nil {
+		return nil, newValidationError(KindMalformed, err)
+	}
+
+	return t, nil
+}
+
+// decode decodes and verifies the token, and returns the decoded token along
+// with its raw payload segment.
+func (p *Parser) decode(token string) (*Token, string, error) {
+	s := strings.Split(token, ".")
+	if len(s) != 3 {
+		return nil, "", newValidationError(KindMalformed, ErrInvalidToken)
+	}
+
+	// The signing input must remain exactly as it was signed, so the padding
+	// is only stripped from the copies used for decoding.
+	tkn := fmt.Sprintf("%s.%s", s[0], s[1])
+	if p.LegacyPadding {
+		for i := range s {
+			s[i] = strings.TrimRight(s[i], "=")
+		}
+	}
+
+	t := NewToken()
+
+	header, err := decodeHeader(t, s[0])
+	if err != nil {
+		return nil, "", newValidationError(KindMalformed, err)
+	}
+
+	if err := p.checkType(header); err != nil {
+		return nil, "", newValidationError(KindMalformed, err)
+	}
+
+	if err := encodedPayload(header); err != nil {
+		return nil, "", newValidationError(KindMalformed, err)
+	}
+
+	if err := decodePayload(t, s[1]); err != nil {
+		return nil, "", newValidationError(KindMalformed, err)
+	}
+
+	if err := p.verify(header, tkn, s[2]); err != nil {
+		return nil, "", newValidationError(KindSignature, err)
+	}
+
+	if err := p.checkCritical(header); err != nil {
+		return nil, "", newValidationError(KindMalformed, err)
+	}
+
+	if p.Validator != nil {
+		if err := p.Validator.Validate(t); err != nil {
+			return nil, "", err
+		}
+	}
+
+	return t, s[1], nil
+}
+
+// verify looks up the algorithm and key for the token and verifies its
+// signature.
+func (p *Parser) verify(header Header, tkn, signature string) error {
+	if p.KeyFunc == nil {
+		return ErrInvalidAlgorithm
+	}
+
+	algorithm, key, err := p.KeyFunc(header)
+	if err != nil {
+		return err
+	}
+
+	if len(algorithm) == 0 {
+		return ErrInvalidAlgorithm
+	}
+
+	if !p.allowed(key, algorithm) {
+		return ErrAlgorithmNotAllowed
+	}
+
+	var verifier Verifier
+	if algorithm != None {
+		var ok bool
+		if _, verifier, ok = LookupAlgorithm(algorithm); !ok {
+			return ErrUnsupportedAlgorithm
+		}
+	}
+
+	if header.Algorithm() != algorithm {
+		return ErrAlgorithmMismatch
+	}
+
+	if algorithm == None {
+		if key != nil {
+			return ErrNoneAlgorithmWithSecret
+		}
+		return nil
+	}
+
+	if err := checkKeyType(algorithm, key); err != nil {
+		return err
+	}
+
+	return verifier(tkn, signature, key)
+}
+
+// checkType makes sure the "typ" header is one of the accepted types.
+func (p *Parser) checkType(header Header) error {
+	v, ok := header["typ"]
+	if !ok {
+		if p.RequireType {
+			return ErrMissingTokenType
+		}
+		return nil
+	}
+
+	if _, ok := v.(string); !ok {
+		return ErrInvalidToken
+	}
+
+	types := p.Types
+	if len(types) == 0 {
+		types = defaultTypes
+	}
+
+	typ := normalizeType(Type(v.(string)))
+	for _, accepted := range types {
+		if normalizeType(accepted) == typ {
+			return nil
+		}
+	}
+
+	return ErrUnsupportedTokenType
+}
+
+// normalizeType converts a media type to lower case and removes the
+// "application/" prefix, unless the type contains another "/", as described
+// in RFC 7515 section 4.1.9.
+func normalizeType(t Type) Type {
+	s := strings.ToLower(string(t))
+	if rest := strings.TrimPrefix(s, "application/"); !strings.Contains(rest, "/") {
+		s = rest
+	}
+
+	return Type(s)
+}
+
+// checkCritical makes sure every parameter listed in the "crit" header is
+// present and understood, and runs the handler of each parameter. As required
+// by RFC 7515, the registered header parameters must not be listed.
+func (p *Parser) checkCritical(header Header) error {
+	crit, err := header.Critical()
+	if err != nil {
+		return err
+	}
+
+	for _, name := range crit {
+		if _, ok := registeredHeaders[name]; ok {
+			return ErrInvalidToken
+		}
+		if _, ok := header[name]; !ok {
+			return ErrInvalidToken
+		}
+		if _, ok := p.Critical[name]; !ok && name != "b64" {
+			return ErrUnsupportedCritical
+		}
+	}
+
+	for _, name := range crit {
+		if handler := p.Critical[name]; handler != nil {
+			if err := handler(header); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// allowed checks if the algorithm is allowed for the key returned by the key
+// function. The "alg" member of a JSON Web Key restricts the key to that
+// algorithm, and its key ID selects the algorithms from KeyAlgorithms.
+func (p *Parser) allowed(key interface{}, algorithm Algorithm) bool {
+	algorithms := p.Algorithms
+	if k, ok := key.(*jwk.Key); ok {
+		if len(k.Algorithm) > 0 && Algorithm(k.Algorithm) != algorithm {
+			return false
+		}
+		if v, ok := p.KeyAlgorithms[k.KeyID]; ok && len(k.KeyID) > 0 {
+			algorithms = v
+		}
+	}
+
+	if len(algorithms) == 0 {
+		return true
+	}
+
+	for _, v := range algorithms {
+		if v == algorithm {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkKeyType makes sure the key belongs to the family of the algorithm, so
+// that for example an RSA public key can never be used as an HMAC secret.
+// Keys of unknown types and custom algorithms are left to the verifier.
+func checkKeyType(algorithm Algorithm, key interface{}) error {
+	kty, ok := keyTypes[algorithm]
+	if !ok {
+		return nil
+	}
+
+	var actual jwk.KeyType
+	switch key.(type) {
+	case *jwk.Key:
+		k := key.(*jwk.Key)
+		if len(k.KeyType) > 0 && k.KeyType != kty {
+			return ErrInvalidKeyType
+		}
+		return checkKeyType(algorithm, k.Key)
+
+	case *rsa.PublicKey, *rsa.PrivateKey:
+		actual = jwk.RSA
+
+	case *ecdsa.PublicKey, *ecdsa.PrivateKey:
+		actual = jwk.EC
+
+	case ed25519.PublicKey, ed25519.PrivateKey, *ed25519.PublicKey, *ed25519.PrivateKey:
+		actual = jwk.OKP
+
+	case *x509.Certificate:
+		if kty == jwk.Oct {
+			return ErrInvalidKeyType
+		}
+		return nil
+
+	default:
+		return nil
+	}
+
+	if actual != kty {
+		return ErrInvalidKeyType
+	}
+
+	return nil
+}
+
+// KeyID returns the "kid" header parameter, or "" if it is not set.
+func (h Header) KeyID() string {
+	return h.stringValue("kid")
+}
+
+// Algorithm returns the "alg" header parameter, or "" if it is not set.
+func (h Header) Algorithm() Algorithm {
+	return Algorithm(h.stringValue("alg"))
+}
+
+// Type returns the "typ" header parameter, or "" if it is not set.
+func (h Header) Type() Type {
+	return Type(h.stringValue("typ"))
+}
+
+// Critical returns the names listed in the "crit" header parameter. An error
+// is returned if the parameter is not a non-empty array of strings.
+func (h Header) Critical() ([]string, error) {
+	v, ok := h["crit"]
+	if !ok {
+		return nil, nil
+	}
+
+	values, ok := v.([]interface{})
+	if !ok || len(values) == 0 {
+		return nil, ErrInvalidToken
+	}
+
+	crit := make([]string, 0, len(values))
+	for _, name := range values {
+		if _, ok := name.(string); !ok {
+			return nil, ErrInvalidToken
+		}
+		crit = append(crit, name.(string))
+	}
+
+	return crit, nil
+}
+
+// stringValue returns the header parameter as a string, or "" if the
+// parameter is not set or is not a string.
+func (h Header) stringValue(name string) string {
+	if v, ok := h[name].(string); ok {
+		return v
+	}
+
+	return ""
+}
+
+// staticKey returns a key function that always returns the given algorithm
+// and secret.
+func staticKey(algorithm Algorithm, secret interface{}) KeyFunc {
+	return func(Header) (Algorithm, interface{}, error) {
+		return algorithm, secret, nil
+	}
+}
+
+// callbackKey returns a key function wrapping a key lookup callback set by
+// KeyLookupCallback. The secret is used when the callback returns no key.
+func callbackKey(callback func(string) (Algorithm, interface{}), secret interface{}) KeyFunc {
+	return func(h Header) (Algorithm, interface{}, error) {
+		kid := h.KeyID()
+		if len(kid) == 0 {
+			return "", nil, ErrNoKeyProvided
+		}
+
+		algorithm, key := callback(kid)
+		if len(string(algorithm)) == 0 {
+			return "", nil, ErrNonExistantKey
+		}
+		if key != nil {
+			if _, ok := key.(string); !ok || (ok && len(key.(string)) > 0) {
+				secret = key
+			}
+		}
+
+		return algorithm, secret, nil
+	}
+}
@@ -0,0 +1,84 @@
+package jwt
+
+import (
+	"sync"
+	"time"
+)
+
+// replaySweepInterval is the minimum time between two sweeps of expired IDs
+// in a MemoryReplayCache.
+const replaySweepInterval = 1 * time.Minute
+
+// ReplayCache is used by a Validator to detect tokens that are used more than
+// once. You can use this type to share used token IDs between servers.
+type ReplayCache interface {
+	// Add records that the token ID has been used until the given time. If
+	// the time is zero, the token has no expiry. Add returns false if the ID
+	// has already been recorded and has not expired yet.
+	Add(id string, expires time.Time) (bool, error)
+}
+
+// MemoryReplayCache is an in-memory ReplayCache, which forgets each token ID
+// once the token expires.
+type MemoryReplayCache struct {
+	// TTL is how long the IDs of tokens without an expiry are remembered. If
+	// zero, they are remembered forever.
+	TTL time.Duration
+
+	// Clock is used to get the current time. If nil, the system clock is used.
+	Clock Clock
+
+	mu    sync.Mutex
+	ids   map[string]time.Time
+	sweep time.Time
+}
+
+// NewMemoryReplayCache creates a new MemoryReplayCache which remembers the IDs
+// of tokens without an expiry for the given duration.
+func NewMemoryReplayCache(ttl time.Duration) *MemoryReplayCache {
+	return &MemoryReplayCache{
+		TTL: ttl,
+	}
+}
+
+// Add records that the token ID has been used until the given time, and
+// returns false if it has already been used.
+func (c *MemoryReplayCache) Add(id string, expires time.Time) (bool, error) {
+	now := c.now()
+	if expires.IsZero() && c.TTL > 0 {
+		expires = now.Add(c.TTL)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.ids == nil {
+		c.ids = make(map[string]time.Time)
+	}
+
+	if now.After(c.sweep) {
+		for k, v := range c.ids {
+			if !v.IsZero() && now.After(v) {
+				delete(c.ids, k)
+			}
+		}
+		c.sweep = now.Add(replaySweepInterval)
+	}
+
+	if v, ok := c.ids[id]; ok && (v.IsZero() || !now.After(v)) {
+		return false, nil
+	}
+
+	c.ids[id] = expires
+
+	return true, nil
+}
+
+// now returns the current time using the configured clock.
+func (c *MemoryReplayCache) now() time.Time {
+	if c.Clock == nil {
+		return systemClock{}.Now()
+	}
+
+	return c.Clock.Now()
+}
@@ -0,0 +1,65 @@
+package jwt
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemoryReplayCacheAdd(t *testing.T) {
+	c := NewMemoryReplayCache(0)
+	c.Clock = fixedClock(ValidatorTime)
+	if ok, _ := c.Add("MyID", ValidatorTime.Add(1*time.Hour)); !ok {
+		t.Fatal("expected true, got false")
+	}
+	if ok, _ := c.Add("MyID", ValidatorTime.Add(1*time.Hour)); ok {
+		t.Fatal("expected false, got true")
+	}
+	if ok, _ := c.Add("MyOtherID", ValidatorTime.Add(1*time.Hour)); !ok {
+		t.Fatal("expected true, got false")
+	}
+}
+
+func TestMemoryReplayCacheAdd_Expired(t *testing.T) {
+	c := NewMemoryReplayCache(0)
+	c.Clock = fixedClock(ValidatorTime)
+	c.Add("MyID", ValidatorTime.Add(1*time.Hour))
+	c.Clock = fixedClock(ValidatorTime.Add(2 * time.Hour))
+	if ok, _ := c.Add("MyID", ValidatorTime.Add(3*time.Hour)); !ok {
+		t.Fatal("expected true, got false")
+	}
+}
+
+func TestMemoryReplayCacheAdd_Sweep(t *testing.T) {
+	c := NewMemoryReplayCache(0)
+	c.Clock = fixedClock(ValidatorTime)
+	c.Add("MyID", ValidatorTime.Add(1*time.Hour))
+	c.Clock = fixedClock(ValidatorTime.Add(2 * time.Hour))
+	c.Add("MyOtherID", ValidatorTime.Add(3*time.Hour))
+	if _, ok := c.ids["MyID"]; ok {
+		t.Fatalf("expected %#q to be removed", "MyID")
+	}
+}
+
+func TestMemoryReplayCacheAdd_TTL(t *testing.T) {
+	c := NewMemoryReplayCache(1 * time.Hour)
+	c.Clock = fixedClock(ValidatorTime)
+	c.Add("MyID", time.Time{})
+	c.Clock = fixedClock(ValidatorTime.Add(30 * time.Minute))
+	if ok, _ := c.Add("MyID", time.Time{}); ok {
+		t.Fatal("expected false, got true")
+	}
+	c.Clock = fixedClock(ValidatorTime.Add(2 * time.Hour))
+	if ok, _ := c.Add("MyID", time.Time{}); !ok {
+		t.Fatal("expected true, got false")
+	}
+}
+
+func TestMemoryReplayCacheAdd_NoExpiry(t *testing.T) {
+	c := NewMemoryReplayCache(0)
+	c.Clock = fixedClock(ValidatorTime)
+	c.Add("MyID", time.Time{})
+	c.Clock = fixedClock(ValidatorTime.Add(24 * time.Hour))
+	if ok, _ := c.Add("MyID", time.Time{}); ok {
+		t.Fatal("expected false, got true")
+	}
+}
@@ -9,9 +9,17 @@ import (
 	"encoding/base64"
 	"encoding/pem"
 	"errors"
+
+	"gopkg.in/zhevron/jwt.v1/jwk"
 )
 
 var (
+	// ErrVerifyFailed is returned when the signature does not match the token.
+	ErrVerifyFailed = errors.New("jwt/rsa: verification failed")
+
+	// ErrInvalidSignature is returned when the signature is not a valid encoding.
+	ErrInvalidSignature = errors.New("jwt/rsa: invalid signature")
+
 	// ErrInvalidKey is returned when the given key is not a valid RSA key.
 	ErrInvalidKey = errors.New("jwt/rsa: invalid key")
 
@@ -79,6 +87,66 @@ func VerifyRS512(token, signature string, secret interface{}) error {
 	return verifySignature(token, signature, crypto.SHA512, k)
 }
 
+// SignPS256 signs a token with the provided secret using RSASSA-PSS SHA-256.
+func SignPS256(token string, secret interface{}) (string, error) {
+	k, err := privateKey(secret)
+	if err != nil {
+		return "", err
+	}
+
+	return computeHashPSS(token, crypto.SHA256, k)
+}
+
+// SignPS384 signs a token with the provided secret using RSASSA-PSS SHA-384.
+func SignPS384(token string, secret interface{}) (string, error) {
+	k, err := privateKey(secret)
+	if err != nil {
+		return "", err
+	}
+
+	return computeHashPSS(token, crypto.SHA384, k)
+}
+
+// SignPS512 signs a token with the provided secret using RSASSA-PSS SHA-512.
+func SignPS512(token string, secret interface{}) (string, error) {
+	k, err := privateKey(secret)
+	if err != nil {
+		return "", err
+	}
+
+	return computeHashPSS(token, crypto.SHA512, k)
+}
+
+// VerifyPS256 verifies the given RSASSA-PSS signature using the given secret.
+func VerifyPS256(token, signature string, secret interface{}) error {
+	k, err := publicKey(secret)
+	if err != nil {
+		return err
+	}
+
+	return verifySignaturePSS(token, signature, crypto.SHA256, k)
+}
+
+// VerifyPS384 verifies the given RSASSA-PSS signature using the given secret.
+func VerifyPS384(token, signature string, secret interface{}) error {
+	k, err := publicKey(secret)
+	if err != nil {
+		return err
+	}
+
+	return verifySignaturePSS(token, signature, crypto.SHA384, k)
+}
+
+// VerifyPS512 verifies the given RSASSA-PSS signature using the given secret.
+func VerifyPS512(token, signature string, secret interface{}) error {
+	k, err := publicKey(secret)
+	if err != nil {
+		return err
+	}
+
+	return verifySignaturePSS(token, signature, crypto.SHA512, k)
+}
+
 // computeHash calculates the hash for a token using the provided algorithm..
 func computeHash(tkn string, h crypto.Hash, k *rsa.PrivateKey) (string, error) {
 	hash := h.New()
@@ -89,7 +157,7 @@ func computeHash(tkn string, h crypto.Hash, k *rsa.PrivateKey) (string, error) {
 		return "", err
 	}
 
-	return base64.URLEncoding.EncodeToString(b), nil
+	return base64.RawURLEncoding.EncodeToString(b), nil
 }
 
 // verifySignature verifies the signature using the given algorithm.
@@ -97,12 +165,69 @@ func verifySignature(tkn, sig string, h crypto.Hash, k *rsa.PublicKey) error {
 	hash := h.New()
 	hash.Write([]byte(tkn))
 
-	b, err := base64.URLEncoding.DecodeString(sig)
+	b, err := base64.RawURLEncoding.DecodeString(sig)
 	if err != nil {
-		return err
+		return ErrInvalidSignature
+	}
+
+	if err := rsa.VerifyPKCS1v15(k, h, hash.Sum(nil), b); err != nil {
+		return ErrVerifyFailed
+	}
+
+	return nil
+}
+
+// pssOptions returns the RSASSA-PSS options for the given hash. RFC 7518
+// requires the salt to be the same length as the hash output.
+func pssOptions(h crypto.Hash) *rsa.PSSOptions {
+	return &rsa.PSSOptions{
+		SaltLength: rsa.PSSSaltLengthEqualsHash,
+		Hash:       h,
+	}
+}
+
+// computeHashPSS calculates the RSASSA-PSS signature for a token using the
+// provided algorithm.
+func computeHashPSS(tkn string, h crypto.Hash, k *rsa.PrivateKey) (string, error) {
+	hash := h.New()
+	hash.Write([]byte(tkn))
+
+	b, err := rsa.SignPSS(rand.Reader, k, h, hash.Sum(nil), pssOptions(h))
+	if err != nil {
+		return "", err
 	}
 
-	return rsa.VerifyPKCS1v15(k, h, hash.Sum(nil), b)
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// verifySignaturePSS verifies the RSASSA-PSS signature using the given algorithm.
+func verifySignaturePSS(tkn, sig string, h crypto.Hash, k *rsa.PublicKey) error {
+	hash := h.New()
+	hash.Write([]byte(tkn))
+
+	b, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	if err := rsa.VerifyPSS(k, h, hash.Sum(nil), b, pssOptions(h)); err != nil {
+		return ErrVerifyFailed
+	}
+
+	return nil
+}
+
+// ParsePrivateKey returns the RSA private key from the secret. It accepts
+// the same types as SignRS256, which makes it possible for other packages to
+// reuse the key parsing.
+func ParsePrivateKey(key interface{}) (*rsa.PrivateKey, error) {
+	return privateKey(key)
+}
+
+// ParsePublicKey returns the RSA public key from the secret. It accepts the
+// same types as VerifyRS256.
+func ParsePublicKey(key interface{}) (*rsa.PublicKey, error) {
+	return publicKey(key)
 }
 
 // privateKey returns the RSA private key from the secret.
@@ -111,6 +236,9 @@ func privateKey(key interface{}) (*rsa.PrivateKey, error) {
 	case *rsa.PrivateKey:
 		return key.(*rsa.PrivateKey), nil
 
+	case *jwk.Key:
+		return privateKey(key.(*jwk.Key).Key)
+
 	case string:
 		return privateKey([]byte(key.(string)))
 
@@ -144,6 +272,9 @@ func publicKey(key interface{}) (*rsa.PublicKey, error) {
 	case *rsa.PublicKey:
 		return key.(*rsa.PublicKey), nil
 
+	case *jwk.Key:
+		return publicKey(key.(*jwk.Key).Public().Key)
+
 	case string:
 		return publicKey([]byte(key.(string)))
 
@@ -1,6 +1,10 @@
 package rsa
 
-import "testing"
+import (
+	"testing"
+
+	"gopkg.in/zhevron/jwt.v1/jwk"
+)
 
 var PublicKey = `-----BEGIN PUBLIC KEY-----
 MIIBIjANBgkqhkiG9w0BAQEFAAOCAQ8AMIIBCgKCAQEAzshs12YDUivJD8MUdBTZ
@@ -68,9 +72,9 @@ YIYRXcQy/0cbvlpYMupFegc=
 -----END PRIVATE KEY-----`
 var Token = "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.eyJpYXQiOjE0MjQ3NzYzMDcsImlzcyI6Ik15SXNzdWVyIiwic2NvcGVzIjpbIm15X3Njb3BlIl19"
 
-var SignatureRS256 = "YnK9-Nz5q-b7cKoVVpA6UPIieLj5XgU4cbyLYGNkYS0_60_9g-s8V0hbeqLXbfDiUBB5JD15zrU--fNbIlYzb_WKgluF6UEza6-dB51_mdFjdXrUmtNyzntidl1Q7GRzuXc6aKu4sbJJd7RA_pMLjDdiMZl2Gkmf2WrX-qoDPQ0y-cRlrvU9G8UhSOFJe_OX07h7dPG9UU7xHbJ9ZY4HfC0zCVEnqSTldZplY31OpM_sg4S45tJKOIGSSPULMRc0WFli_SycqWJA44fakZvLQzcxpcuhMj3qYoSKaNvtILfH2vhG63qreo-9loR7vKQ96S1tQfEq6SrD2kBYkF-E8g=="
-var SignatureRS384 = "tk5fiP9SNd1dptLDkm2GZA6jifb8HQKH2CrX_Pkd_Fds9F7DBpMXbpH4NpbjXMqCxuX7scFk-et8sF0ugmh7oJy54W2trHRfk318E_iUJvoE9NtSItye-eglU5Ux7BH1uRUal5fYzSRF9jnscEKhWXBntzaYONb4lQrWC9fUbvPxGnS3_vRfJl9uKOD7Pz3VcbXCos6TFmL3WMNydm5QIabh2BAdJpguOj_mEzjDl9vMew1WsDsS7LPUYDWvFr573yAmk67YJZPqMZ0lbidKNAlbrPLl6sCL94pQQeq_oJZNlEcS0ZwJlsxrx3hcN5QDLEkfaONAcZKzRIzjBsBUtg=="
-var SignatureRS512 = "BLOePcjJWLKWjCxFrvsiuL7l_To8Xwn_w6uMeRtR91VEBRuXxPeqix8T8Q8k9azou3TBV_kAx1uFvdPQXJjYlqndcSZ2M76QYIOPamfWsi-sqgIU7q4jNUkQKIY2yRwYUle49vZ53Um1WWkaZ7LpToi2Cz8dEi0GyAsfRJwzZZ015jVQ0d5InxEo8rNeEGtkRfPKLtSwwmRxJDlNvB3TaQ4AcFGeeC9-kUa8NyHYmvxZ9HEanIAaM4lcRf2naq7Rznh_vqq3twFEjrm8TMyoZmajGIpWCCmQRvRCJLfU1zQC1eFjPXyIPf05cJFnGP0L3N8GUqnBJkva4E8zkRFJTw=="
+var SignatureRS256 = "YnK9-Nz5q-b7cKoVVpA6UPIieLj5XgU4cbyLYGNkYS0_60_9g-s8V0hbeqLXbfDiUBB5JD15zrU--fNbIlYzb_WKgluF6UEza6-dB51_mdFjdXrUmtNyzntidl1Q7GRzuXc6aKu4sbJJd7RA_pMLjDdiMZl2Gkmf2WrX-qoDPQ0y-cRlrvU9G8UhSOFJe_OX07h7dPG9UU7xHbJ9ZY4HfC0zCVEnqSTldZplY31OpM_sg4S45tJKOIGSSPULMRc0WFli_SycqWJA44fakZvLQzcxpcuhMj3qYoSKaNvtILfH2vhG63qreo-9loR7vKQ96S1tQfEq6SrD2kBYkF-E8g"
+var SignatureRS384 = "tk5fiP9SNd1dptLDkm2GZA6jifb8HQKH2CrX_Pkd_Fds9F7DBpMXbpH4NpbjXMqCxuX7scFk-et8sF0ugmh7oJy54W2trHRfk318E_iUJvoE9NtSItye-eglU5Ux7BH1uRUal5fYzSRF9jnscEKhWXBntzaYONb4lQrWC9fUbvPxGnS3_vRfJl9uKOD7Pz3VcbXCos6TFmL3WMNydm5QIabh2BAdJpguOj_mEzjDl9vMew1WsDsS7LPUYDWvFr573yAmk67YJZPqMZ0lbidKNAlbrPLl6sCL94pQQeq_oJZNlEcS0ZwJlsxrx3hcN5QDLEkfaONAcZKzRIzjBsBUtg"
+var SignatureRS512 = "BLOePcjJWLKWjCxFrvsiuL7l_To8Xwn_w6uMeRtR91VEBRuXxPeqix8T8Q8k9azou3TBV_kAx1uFvdPQXJjYlqndcSZ2M76QYIOPamfWsi-sqgIU7q4jNUkQKIY2yRwYUle49vZ53Um1WWkaZ7LpToi2Cz8dEi0GyAsfRJwzZZ015jVQ0d5InxEo8rNeEGtkRfPKLtSwwmRxJDlNvB3TaQ4AcFGeeC9-kUa8NyHYmvxZ9HEanIAaM4lcRf2naq7Rznh_vqq3twFEjrm8TMyoZmajGIpWCCmQRvRCJLfU1zQC1eFjPXyIPf05cJFnGP0L3N8GUqnBJkva4E8zkRFJTw"
 
 func TestSignRS256(t *testing.T) {
 	if _, err := SignRS256(Token, PrivateKeyPKCS1); err != nil {
@@ -161,3 +165,129 @@ func TestVerifyRS512_Fail(t *testing.T) {
 		t.Fatal("expected non-nil, got nil")
 	}
 }
+
+func TestVerifyRS256_VerifyFailed(t *testing.T) {
+	if err := VerifyRS256(Token, SignatureRS384, PublicKey); err != ErrVerifyFailed {
+		t.Fatalf("expected %#q, got %#q", ErrVerifyFailed, err)
+	}
+}
+
+func TestVerifyRS256_InvalidSignature(t *testing.T) {
+	if err := VerifyRS256(Token, "!", PublicKey); err != ErrInvalidSignature {
+		t.Fatalf("expected %#q, got %#q", ErrInvalidSignature, err)
+	}
+}
+
+func TestSignPS256(t *testing.T) {
+	if _, err := SignPS256(Token, PrivateKeyPKCS1); err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+}
+
+func TestSignPS256_PKCS8(t *testing.T) {
+	if _, err := SignPS256(Token, PrivateKeyPKCS8); err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+}
+
+func TestSignPS256_UnsupportedKeyType(t *testing.T) {
+	if _, err := SignPS256(Token, 0); err != ErrUnsupportedKeyType {
+		t.Fatalf("expected %#q, got %#q", ErrUnsupportedKeyType, err)
+	}
+}
+
+func TestSignPS384(t *testing.T) {
+	if _, err := SignPS384(Token, PrivateKeyPKCS1); err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+}
+
+func TestSignPS512(t *testing.T) {
+	if _, err := SignPS512(Token, PrivateKeyPKCS1); err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+}
+
+func TestVerifyPS256(t *testing.T) {
+	sig, err := SignPS256(Token, PrivateKeyPKCS1)
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+	if err := VerifyPS256(Token, sig, PublicKey); err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+}
+
+func TestVerifyPS384(t *testing.T) {
+	sig, err := SignPS384(Token, PrivateKeyPKCS1)
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+	if err := VerifyPS384(Token, sig, PublicKey); err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+}
+
+func TestVerifyPS512(t *testing.T) {
+	sig, err := SignPS512(Token, PrivateKeyPKCS1)
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+	if err := VerifyPS512(Token, sig, PublicKey); err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+}
+
+func TestVerifyPS256_Fail(t *testing.T) {
+	if VerifyPS256(Token, SignatureRS256, PublicKey) == nil {
+		t.Fatal("expected non-nil, got nil")
+	}
+}
+
+func TestVerifyPS256_VerifyFailed(t *testing.T) {
+	if err := VerifyPS256(Token, SignatureRS256, PublicKey); err != ErrVerifyFailed {
+		t.Fatalf("expected %#q, got %#q", ErrVerifyFailed, err)
+	}
+}
+
+func TestVerifyPS384_Fail(t *testing.T) {
+	if VerifyPS384(Token, SignatureRS384, PublicKey) == nil {
+		t.Fatal("expected non-nil, got nil")
+	}
+}
+
+func TestVerifyPS512_Fail(t *testing.T) {
+	if VerifyPS512(Token, SignatureRS512, PublicKey) == nil {
+		t.Fatal("expected non-nil, got nil")
+	}
+}
+
+func TestSignRS256_JWK(t *testing.T) {
+	k, err := privateKey(PrivateKeyPKCS1)
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+	sig, err := SignRS256(Token, &jwk.Key{Key: k})
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+	if err := VerifyRS256(Token, sig, &jwk.Key{Key: k}); err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+}
+
+func TestVerifyRS256_JWK(t *testing.T) {
+	k, err := publicKey(PublicKey)
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+	if err := VerifyRS256(Token, SignatureRS256, &jwk.Key{Key: k}); err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+}
+
+func TestSignRS256_JWKInvalidKey(t *testing.T) {
+	if _, err := SignRS256(Token, &jwk.Key{Key: []byte("secret")}); err == nil {
+		t.Fatal("expected non-nil, got nil")
+	}
+}
@@ -0,0 +1,151 @@
+package jwt
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+
+	"gopkg.in/zhevron/jwt.v1/ecdsa"
+	"gopkg.in/zhevron/jwt.v1/eddsa"
+	"gopkg.in/zhevron/jwt.v1/hmac"
+	"gopkg.in/zhevron/jwt.v1/jwk"
+	"gopkg.in/zhevron/jwt.v1/rsa"
+)
+
+// WithThumbprintKeyID makes the token set the "kid" header to the RFC 7638
+// SHA-256 thumbprint of the signing key when it is signed, unless KeyID is
+// already set. The thumbprint of an HMAC secret is derived from the secret
+// itself, so only use this with secrets that cannot be guessed.
+func WithThumbprintKeyID() TokenOption {
+	return func(t *Token) {
+		t.thumbprintKeyID = true
+	}
+}
+
+// WithCertificateThumbprint sets the "x5t" and "x5t#S256" headers of the
+// token to the SHA-1 and SHA-256 thumbprints of the certificate, so the
+// recipient can select the verification key using CertificateThumbprintFunc.
+func WithCertificateThumbprint(c *x509.Certificate) TokenOption {
+	return func(t *Token) {
+		t.Headers["x5t"] = base64.RawURLEncoding.EncodeToString(certificateThumbprint(c, crypto.SHA1))
+		t.Headers["x5t#S256"] = base64.RawURLEncoding.EncodeToString(certificateThumbprint(c, crypto.SHA256))
+	}
+}
+
+// CertificateThumbprintFunc returns a key function that selects the
+// verification key from the given certificates using the "x5t#S256" header of
+// the token, or the "x5t" header if it is not set.
+//
+// Decoding fails with ErrNoKeyProvided if the token has neither header and
+// with ErrNonExistantKey if no certificate matches. The certificates are not
+// verified, so they must already be trusted. Use Parser.Algorithms to
+// restrict the algorithms that are accepted.
+func CertificateThumbprintFunc(certs ...*x509.Certificate) KeyFunc {
+	return func(h Header) (Algorithm, interface{}, error) {
+		hash := crypto.SHA256
+		v, ok := h["x5t#S256"]
+		if !ok {
+			hash = crypto.SHA1
+			v, ok = h["x5t"]
+		}
+		if !ok {
+			return "", nil, ErrNoKeyProvided
+		}
+
+		if _, ok := v.(string); !ok {
+			return "", nil, ErrInvalidToken
+		}
+		thumbprint, err := base64.RawURLEncoding.DecodeString(v.(string))
+		if err != nil {
+			return "", nil, ErrInvalidToken
+		}
+
+		for _, c := range certs {
+			if bytes.Equal(certificateThumbprint(c, hash), thumbprint) {
+				return h.Algorithm(), c.PublicKey, nil
+			}
+		}
+
+		return "", nil, ErrNonExistantKey
+	}
+}
+
+// setThumbprintKeyID sets the key ID to the thumbprint of the signing key, if
+// enabled using WithThumbprintKeyID.
+func (t *Token) setThumbprintKeyID(secret interface{}) error {
+	if !t.thumbprintKeyID || len(t.KeyID) > 0 || t.Algorithm == None {
+		return nil
+	}
+
+	k, err := thumbprintKey(t.Algorithm, secret)
+	if err != nil {
+		return err
+	}
+
+	b, err := k.Thumbprint(crypto.SHA256)
+	if err != nil {
+		return err
+	}
+	t.KeyID = base64.RawURLEncoding.EncodeToString(b)
+
+	return nil
+}
+
+// thumbprintKey returns the signing key used with the algorithm as a JSON Web
+// Key, parsing PEM encoded keys the same way as the signing packages.
+func thumbprintKey(algorithm Algorithm, secret interface{}) (*jwk.Key, error) {
+	switch k := secret.(type) {
+	case *jwk.Key:
+		return k, nil
+
+	case hmac.MinKeyLength:
+		return thumbprintKey(algorithm, k.Key)
+	}
+
+	var key interface{}
+	var err error
+
+	switch keyTypes[algorithm] {
+	case jwk.Oct:
+		switch secret.(type) {
+		case string:
+			key = []byte(secret.(string))
+
+		case []byte:
+			key = secret
+		}
+
+	case jwk.RSA:
+		key, err = rsa.ParsePrivateKey(secret)
+
+	case jwk.EC:
+		key, err = ecdsa.ParsePrivateKey(secret)
+
+	case jwk.OKP:
+		key, err = eddsa.ParsePrivateKey(secret)
+
+	default:
+		key = secret
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return jwk.NewKey(key)
+}
+
+// certificateThumbprint returns the thumbprint of the DER encoding of the
+// certificate using the given hash function.
+func certificateThumbprint(c *x509.Certificate, h crypto.Hash) []byte {
+	if h == crypto.SHA1 {
+		sum := sha1.Sum(c.Raw)
+		return sum[:]
+	}
+
+	sum := sha256.Sum256(c.Raw)
+	return sum[:]
+}
@@ -0,0 +1,154 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"testing"
+
+	"gopkg.in/zhevron/jwt.v1/hmac"
+	"gopkg.in/zhevron/jwt.v1/jwk"
+)
+
+func TestTokenSign_ThumbprintKeyID(t *testing.T) {
+	str, err := NewToken(WithThumbprintKeyID()).Sign("secret")
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+
+	tkn, err := DecodeToken(str, HS256, "secret")
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+	sum := sha256.Sum256([]byte(`{"k":"c2VjcmV0","kty":"oct"}`))
+	if expected := base64.RawURLEncoding.EncodeToString(sum[:]); tkn.KeyID != expected {
+		t.Fatalf("expected %#q, got %#q", expected, tkn.KeyID)
+	}
+}
+
+func TestTokenSign_ThumbprintKeyIDMinKeyLength(t *testing.T) {
+	key := make([]byte, 32)
+	str, err := NewToken(WithThumbprintKeyID()).Sign(hmac.MinKeyLength{Key: key})
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+
+	tkn, err := DecodeToken(str, HS256, key)
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+	if len(tkn.KeyID) == 0 {
+		t.Fatal("expected non-empty key id")
+	}
+}
+
+func TestTokenSign_ThumbprintKeyIDECDSA(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tkn := NewToken(WithThumbprintKeyID())
+	tkn.Algorithm = ES256
+	str, err := tkn.Sign(key)
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+
+	k, err := jwk.NewKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := k.Thumbprint(crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := DecodeToken(str, ES256, &key.PublicKey)
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+	if expected := base64.RawURLEncoding.EncodeToString(b); decoded.KeyID != expected {
+		t.Fatalf("expected %#q, got %#q", expected, decoded.KeyID)
+	}
+}
+
+func TestTokenSign_ThumbprintKeyIDPreset(t *testing.T) {
+	tkn := NewToken(WithThumbprintKeyID())
+	tkn.KeyID = "MyKey"
+	str, err := tkn.Sign("secret")
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+
+	decoded, err := DecodeToken(str, HS256, "secret")
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+	if decoded.KeyID != "MyKey" {
+		t.Fatalf("expected %#q, got %#q", "MyKey", decoded.KeyID)
+	}
+}
+
+func TestTokenSign_ThumbprintKeyIDInvalidKey(t *testing.T) {
+	tkn := NewToken(WithThumbprintKeyID())
+	tkn.Algorithm = RS256
+	if _, err := tkn.Sign("secret"); err == nil {
+		t.Fatal("expected non nil, got nil")
+	}
+}
+
+func TestCertificateThumbprintFunc(t *testing.T) {
+	cert, key := testCertificate(t, &x509.Certificate{SerialNumber: big.NewInt(1)}, nil, nil)
+	other, _ := testCertificate(t, &x509.Certificate{SerialNumber: big.NewInt(2)}, nil, nil)
+
+	tkn := NewToken(WithCertificateThumbprint(cert))
+	tkn.Algorithm = ES256
+	str, err := tkn.Sign(key)
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+
+	if _, err := NewParser(CertificateThumbprintFunc(other, cert)).Decode(str); err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+
+	_, err = NewParser(CertificateThumbprintFunc(other)).Decode(str)
+	if !errors.Is(err, ErrNonExistantKey) {
+		t.Fatalf("expected %#q, got %#q", ErrNonExistantKey, err)
+	}
+}
+
+func TestCertificateThumbprintFunc_SHA1(t *testing.T) {
+	cert, key := testCertificate(t, &x509.Certificate{SerialNumber: big.NewInt(1)}, nil, nil)
+
+	tkn := NewToken(WithCertificateThumbprint(cert))
+	tkn.Algorithm = ES256
+	delete(tkn.Headers, "x5t#S256")
+	str, err := tkn.Sign(key)
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+
+	if _, err := NewParser(CertificateThumbprintFunc(cert)).Decode(str); err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+}
+
+func TestCertificateThumbprintFunc_NoThumbprint(t *testing.T) {
+	str, err := NewToken().Sign("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = NewParser(CertificateThumbprintFunc()).Decode(str)
+	if !errors.Is(err, ErrNoKeyProvided) {
+		t.Fatalf("expected %#q, got %#q", ErrNoKeyProvided, err)
+	}
+}
@@ -1,10 +1,11 @@
 package jwt
 
 import (
+	"crypto/rand"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
-	"strings"
+	"reflect"
 	"time"
 )
 
@@ -15,26 +16,54 @@ type Token struct {
 	KeyID     string
 	Issuer    string
 	Subject   string
-	Audience  string
+	Audience  Audience
+	ID        string
 	IssuedAt  time.Time
 	Expires   time.Time
 	NotBefore time.Time
+	Headers   map[string]interface{}
 	Claims    map[string]interface{}
+
+	// thumbprintKeyID is set by WithThumbprintKeyID.
+	thumbprintKeyID bool
+}
+
+// TokenOption is used to configure a new Token.
+type TokenOption func(*Token)
+
+// WithRandomID sets the ID of the token to a random 128-bit value, which
+// makes it possible to detect replayed tokens with a ReplayCache. It panics if
+// the system random number generator fails.
+func WithRandomID() TokenOption {
+	return func(t *Token) {
+		b := make([]byte, 16)
+		if _, err := rand.Read(b); err != nil {
+			panic("jwt: failed to generate token id: " + err.Error())
+		}
+		t.ID = base64.RawURLEncoding.EncodeToString(b)
+	}
 }
 
 // NewToken creates a new Token struct using the default HS256 algorithm.
 // IssuedAt is also initialized to the current UTC time.
-func NewToken() *Token {
+func NewToken(opts ...TokenOption) *Token {
 	now := time.Now().UTC()
 
-	return &Token{
+	t := &Token{
 		Type:      JWT,
 		Algorithm: HS256,
 		IssuedAt:  now,
 		Expires:   now,
 		NotBefore: now,
+		Headers:   make(map[string]interface{}),
 		Claims:    make(map[string]interface{}),
 	}
+
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	return t
 }
 
 // DecodeToken attempts to decode a JWT into a Token structure using the given
@@ -44,80 +73,65 @@ func NewToken() *Token {
 // The secret parameter type is variable. All algorithms support string
 // and []byte types, but some also have other custom types.
 //
-// Note: The "alg" field of the token header is completely ignored in order
-// to ensure that a token is what the server expects.
+// Note: The "alg" field of the token header is never used to choose the
+// algorithm. It must match the given algorithm, in order to ensure that a
+// token is what the server expects.
 func DecodeToken(token string, algorithm Algorithm, secret interface{}) (*Token, error) {
-	s := strings.Split(token, ".")
-	if len(s) != 3 {
-		return nil, ErrInvalidToken
-	}
-
-	t := NewToken()
-
-	if err := decodeHeader(t, s[0]); err != nil {
-		return nil, err
-	}
+	return defaultParser(algorithm, secret).Decode(token)
+}
 
-	if err := decodePayload(t, s[1]); err != nil {
-		return nil, err
+// defaultParser creates the Parser used by DecodeToken, which honours the
+// global key lookup callback.
+func defaultParser(algorithm Algorithm, secret interface{}) *Parser {
+	p := &Parser{
+		KeyFunc: staticKey(algorithm, secret),
 	}
 
 	if keyLookupCallback != nil {
-		if len(t.KeyID) == 0 {
-			return nil, ErrNoKeyProvided
-		}
-
-		var key interface{}
-		algorithm, key = keyLookupCallback(t.KeyID)
-		if len(string(algorithm)) == 0 {
-			return nil, ErrNonExistantKey
-		}
-		if key != nil {
-			if _, ok := key.(string); !ok || (ok && len(key.(string)) > 0) {
-				secret = key
-			}
-		}
+		p.KeyFunc = callbackKey(keyLookupCallback, secret)
 	}
 
-	if algorithm != None {
-		tkn := fmt.Sprintf("%s.%s", s[0], s[1])
-
-		pair, ok := supportedAlgorithms[algorithm]
-		if !ok {
-			return nil, ErrUnsupportedAlgorithm
-		}
-
-		if err := pair.Verifier(tkn, s[2], secret); err != nil {
-			return nil, err
-		}
-	} else {
-		if secret != nil {
-			return nil, ErrNoneAlgorithmWithSecret
-		}
-	}
+	return p
+}
 
-	return t, nil
+// DecodeTokenFunc attempts to decode a JWT into a Token structure using the
+// algorithm and secret returned by keyFunc for verification.
+//
+// Unlike DecodeToken, this function does not use the global key lookup
+// callback, which makes it safe to use with different key sources in the
+// same program.
+func DecodeTokenFunc(token string, keyFunc KeyFunc) (*Token, error) {
+	return NewParser(keyFunc).Decode(token)
 }
 
-// decodeHeader attempts to decode the JWT header.
-func decodeHeader(t *Token, s string) error {
-	b, err := base64.URLEncoding.DecodeString(s)
+// decodeHeader attempts to decode the JWT header. The decoded header is
+// returned for use by the key function.
+func decodeHeader(t *Token, s string) (Header, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	header := make(map[string]interface{})
+	header := make(Header)
 	if err := json.Unmarshal(b, &header); err != nil {
-		return err
+		return nil, err
+	}
+
+	if err := readHeader(t, header); err != nil {
+		return nil, err
 	}
 
+	return header, nil
+}
+
+// readHeader reads the parameters of a decoded header into the token. The
+// registered parameters are read into their fields, and any other parameters
+// into Headers.
+func readHeader(t *Token, header Header) error {
 	if v, ok := header["typ"]; ok {
 		if _, ok := v.(string); !ok {
 			return ErrInvalidToken
 		}
-		if _, ok := supportedTypes[Type(v.(string))]; !ok {
-			return ErrUnsupportedTokenType
-		}
 		t.Type = Type(v.(string))
 	}
 
@@ -135,12 +149,19 @@ func decodeHeader(t *Token, s string) error {
 		t.KeyID = v.(string)
 	}
 
+	for k, v := range header {
+		if _, ok := reservedHeaders[k]; ok {
+			continue
+		}
+		t.Headers[k] = v
+	}
+
 	return nil
 }
 
 // decodePayload attempts to decode the JWT payload.
 func decodePayload(t *Token, s string) error {
-	b, err := base64.URLEncoding.DecodeString(s)
+	b, err := base64.RawURLEncoding.DecodeString(s)
 	if err != nil {
 		return err
 	}
@@ -150,6 +171,11 @@ func decodePayload(t *Token, s string) error {
 		return err
 	}
 
+	// The time claims are only checked if they are present in the token, so
+	// they must not keep the decoding time set by NewToken.
+	t.IssuedAt = time.Time{}
+	t.NotBefore = time.Time{}
+
 	if v, ok := payload["iss"]; ok {
 		if _, ok := v.(string); !ok {
 			return ErrInvalidToken
@@ -163,10 +189,17 @@ func decodePayload(t *Token, s string) error {
 		t.Subject = v.(string)
 	}
 	if v, ok := payload["aud"]; ok {
+		aud, ok := audienceValue(v)
+		if !ok {
+			return ErrInvalidToken
+		}
+		t.Audience = aud
+	}
+	if v, ok := payload["jti"]; ok {
 		if _, ok := v.(string); !ok {
 			return ErrInvalidToken
 		}
-		t.Audience = v.(string)
+		t.ID = v.(string)
 	}
 
 	if v, ok := payload["iat"]; ok {
@@ -186,6 +219,10 @@ func decodePayload(t *Token, s string) error {
 			return ErrInvalidToken
 		}
 		t.Expires = time.Unix(int64(v.(float64)), 0)
+	} else {
+		// A token without "exp" never expires, which is represented by
+		// Expires being equal to IssuedAt.
+		t.Expires = t.IssuedAt
 	}
 
 	for k, v := range payload {
@@ -205,37 +242,69 @@ func decodePayload(t *Token, s string) error {
 // and []byte types, but some also have other custom types.
 // Refer to the documentation in each encryption package for the options.
 func (t Token) Sign(secret interface{}) (string, error) {
-	header, err := json.Marshal(t.buildHeader())
+	claims, err := t.buildClaims()
 	if err != nil {
 		return "", err
 	}
 
-	claims, err := t.buildClaims()
+	payload, err := json.Marshal(claims)
 	if err != nil {
 		return "", err
 	}
 
+	return t.sign(payload, secret)
+}
+
+// SignClaims signs the given claims with the provided secret and returns the
+// base64 encoded string value of the token. The claims are usually a struct
+// embedding RegisteredClaims.
+//
+// Only the header fields of the token are used. The registered claims of the
+// token, such as Issuer and IssuedAt, are ignored and must be set in the
+// claims instead. ErrReservedClaim is returned if any of the other claims use
+// a reserved claim name.
+func (t Token) SignClaims(claims interface{}, secret interface{}) (string, error) {
+	if err := checkClaims(reflect.ValueOf(claims)); err != nil {
+		return "", err
+	}
+
 	payload, err := json.Marshal(claims)
 	if err != nil {
 		return "", err
 	}
 
+	if len(payload) == 0 || payload[0] != '{' {
+		return "", ErrInvalidToken
+	}
+
+	return t.sign(payload, secret)
+}
+
+// sign signs the encoded payload using the header fields of the token.
+func (t Token) sign(payload []byte, secret interface{}) (string, error) {
+	if err := t.setThumbprintKeyID(secret); err != nil {
+		return "", err
+	}
+
+	h, err := t.buildHeader()
+	if err != nil {
+		return "", err
+	}
+
+	header, err := json.Marshal(h)
+	if err != nil {
+		return "", err
+	}
+
 	tkn := fmt.Sprintf(
 		"%s.%s",
-		base64.URLEncoding.EncodeToString(header),
-		base64.URLEncoding.EncodeToString(payload),
+		base64.RawURLEncoding.EncodeToString(header),
+		base64.RawURLEncoding.EncodeToString(payload),
 	)
 
-	signature := ""
-	if t.Algorithm != None {
-		pair, ok := supportedAlgorithms[t.Algorithm]
-		if !ok {
-			return "", ErrUnsupportedAlgorithm
-		}
-		signature, err = pair.Signer(tkn, secret)
-		if err != nil {
-			return "", err
-		}
+	signature, err := signInput(t.Algorithm, tkn, secret)
+	if err != nil {
+		return "", err
 	}
 
 	return fmt.Sprintf(
@@ -245,58 +314,112 @@ func (t Token) Sign(secret interface{}) (string, error) {
 	), nil
 }
 
-// Verify attempts to verify the token using the provided issuer, subject and
-// audience. If either provided value is left empty, the value is skipped.
-// Validity and expiration will also be checked.
-func (t Token) Verify(issuer, subject, audience string) error {
-	if len(issuer) > 0 && issuer != t.Issuer {
-		return ErrInvalidIssuer
+// signInput signs the signing input using the given algorithm. Tokens using
+// the "none" algorithm have an empty signature.
+func signInput(algorithm Algorithm, input string, secret interface{}) (string, error) {
+	if algorithm == None {
+		return "", nil
 	}
 
-	if len(subject) > 0 && subject != t.Subject {
-		return ErrInvalidSubject
+	signer, _, ok := LookupAlgorithm(algorithm)
+	if !ok {
+		return "", ErrUnsupportedAlgorithm
 	}
 
-	if len(audience) > 0 && audience != t.Audience {
-		return ErrInvalidAudience
-	}
+	return signer(input, secret)
+}
 
-	if !t.Valid() {
-		return ErrTokenNotValidYet
+// Verify attempts to verify the token using the provided issuer, subject and
+// audiences. If either provided value is left empty, the value is skipped.
+// The audience check passes if any of the token's audiences matches any of
+// the provided audiences. Validity and expiration will also be checked.
+//
+// If any check fails, a ValidationError containing every failed check is
+// returned.
+//
+// The "iat" claim is not checked. Use a Validator to check it, to allow for
+// clock skew or to use a different clock.
+func (t Token) Verify(issuer, subject string, audience ...string) error {
+	v := &Validator{
+		Issuer:       issuer,
+		Subject:      subject,
+		Audience:     audience,
+		skipIssuedAt: true,
 	}
 
-	if t.Expired() {
-		return ErrTokenExpired
+	return v.Validate(&t)
+}
+
+// verifyAudience checks if any of the token's audiences matches any of the
+// expected audiences. Empty expected audiences are ignored, and the check
+// passes if no audience is expected.
+func (t Token) verifyAudience(expected []string) bool {
+	checked := false
+	for _, aud := range expected {
+		if len(aud) == 0 {
+			continue
+		}
+		if t.Audience.Contains(aud) {
+			return true
+		}
+		checked = true
 	}
 
-	return nil
+	return !checked
 }
 
 // Valid checks if the token is valid yet.
 func (t Token) Valid() bool {
-	return t.NotBefore.Before(time.Now().UTC())
+	return t.validAt(systemClock{}.Now(), 0)
 }
 
 // Expired checks if the token has expired.
 func (t Token) Expired() bool {
+	return t.expiredAt(systemClock{}.Now(), 0)
+}
+
+// validAt checks if the token is valid at the given time, allowing for the
+// given amount of clock skew.
+func (t Token) validAt(now time.Time, leeway time.Duration) bool {
+	return t.NotBefore.Before(now.Add(leeway))
+}
+
+// expiredAt checks if the token has expired at the given time, allowing for
+// the given amount of clock skew.
+func (t Token) expiredAt(now time.Time, leeway time.Duration) bool {
 	if t.IssuedAt.Equal(t.Expires) {
 		return false
 	}
 
-	return time.Now().UTC().After(t.Expires)
+	return now.Add(-leeway).After(t.Expires)
+}
+
+// issuedAt checks if the token has been issued at the given time, allowing
+// for the given amount of clock skew.
+func (t Token) issuedAt(now time.Time, leeway time.Duration) bool {
+	return !t.IssuedAt.After(now.Add(leeway))
 }
 
 // buildHeader builds a new header map ready for signing.
-func (t Token) buildHeader() map[string]interface{} {
+func (t Token) buildHeader() (map[string]interface{}, error) {
 	header := make(map[string]interface{})
 
-	header["typ"] = t.Type
+	if len(t.Type) > 0 {
+		header["typ"] = t.Type
+	}
 	header["alg"] = t.Algorithm
 	if len(t.KeyID) > 0 {
 		header["kid"] = t.KeyID
 	}
 
-	return header
+	for k, v := range t.Headers {
+		if _, ok := reservedHeaders[k]; ok {
+			return header, ErrReservedHeader
+		}
+		header[k] = v
+	}
+
+	return header, nil
 }
 
 // buildClaims builds a new claims map ready for signing.
@@ -312,9 +435,14 @@ func (t Token) buildClaims() (map[string]interface{}, error) {
 	if len(t.Audience) > 0 {
 		claims["aud"] = t.Audience
 	}
+	if len(t.ID) > 0 {
+		claims["jti"] = t.ID
+	}
 
-	claims["iat"] = t.IssuedAt.Unix()
-	if t.NotBefore.Before(t.IssuedAt) {
+	if !t.IssuedAt.IsZero() {
+		claims["iat"] = t.IssuedAt.Unix()
+	}
+	if !t.NotBefore.IsZero() {
 		claims["nbf"] = t.NotBefore.Unix()
 	}
 	if t.Expires.After(t.IssuedAt) {
@@ -0,0 +1,134 @@
+package jwt
+
+import "time"
+
+// Clock is used to get the current time for all time-based validation.
+// You can use this type to freeze time in tests.
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc is used to use an ordinary function as a Clock.
+type ClockFunc func() time.Time
+
+// Now returns the current time by calling the function.
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+// systemClock is the Clock used when none is configured.
+type systemClock struct{}
+
+// Now returns the current UTC time.
+func (systemClock) Now() time.Time {
+	return time.Now().UTC()
+}
+
+// Validator contains the configuration used to validate the claims of a token.
+type Validator struct {
+	// Issuer is the expected issuer. If empty, the issuer is not checked.
+	Issuer string
+
+	// Subject is the expected subject. If empty, the subject is not checked.
+	Subject string
+
+	// Audience contains the expected audiences. The check passes if any of the
+	// token's audiences matches any of these. If empty, the audience is not
+	// checked.
+	Audience []string
+
+	// Leeway is the amount of clock skew allowed when checking the "nbf",
+	// "exp" and "iat" claims.
+	Leeway time.Duration
+
+	// Clock is used to get the current time. If nil, the system clock is used.
+	Clock Clock
+
+	// ReplayCache is used to reject tokens whose ID has already been used.
+	// If nil, replayed tokens are not detected.
+	ReplayCache ReplayCache
+
+	// skipIssuedAt disables the "iat" check. It is used by Token.Verify,
+	// which has never checked "iat" and has no leeway to tolerate clock skew.
+	skipIssuedAt bool
+}
+
+// Validate checks the claims of the token against the configuration. If any
+// check fails, a ValidationError of kind KindClaims is returned, containing a
+// ClaimError for every failed check.
+func (v *Validator) Validate(t *Token) error {
+	var errs []error
+
+	if len(v.Issuer) > 0 && v.Issuer != t.Issuer {
+		errs = append(errs, &ClaimError{"iss", t.Issuer, ErrInvalidIssuer})
+	}
+
+	if len(v.Subject) > 0 && v.Subject != t.Subject {
+		errs = append(errs, &ClaimError{"sub", t.Subject, ErrInvalidSubject})
+	}
+
+	if !t.verifyAudience(v.Audience) {
+		errs = append(errs, &ClaimError{"aud", t.Audience, ErrInvalidAudience})
+	}
+
+	now := v.now()
+
+	if !v.skipIssuedAt && !t.issuedAt(now, v.Leeway) {
+		errs = append(errs, &ClaimError{"iat", t.IssuedAt, ErrTokenUsedBeforeIssued})
+	}
+
+	if !t.validAt(now, v.Leeway) {
+		errs = append(errs, &ClaimError{"nbf", t.NotBefore, ErrTokenNotValidYet})
+	}
+
+	if t.expiredAt(now, v.Leeway) {
+		errs = append(errs, &ClaimError{"exp", t.Expires, ErrTokenExpired})
+	}
+
+	// Only valid tokens are recorded, so a rejected token can be used once it
+	// becomes valid.
+	if len(errs) == 0 && v.ReplayCache != nil {
+		if err := v.checkReplay(t); err != nil {
+			errs = append(errs, &ClaimError{"jti", t.ID, err})
+		}
+	}
+
+	if len(errs) > 0 {
+		return newValidationError(KindClaims, errs...)
+	}
+
+	return nil
+}
+
+// checkReplay records the ID of the token in the replay cache and makes sure
+// it has not been used before.
+func (v *Validator) checkReplay(t *Token) error {
+	if len(t.ID) == 0 {
+		return ErrMissingTokenID
+	}
+
+	// The ID must be remembered for as long as the token would be accepted.
+	var expires time.Time
+	if !t.IssuedAt.Equal(t.Expires) {
+		expires = t.Expires.Add(v.Leeway)
+	}
+
+	ok, err := v.ReplayCache.Add(t.ID, expires)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrTokenReplayed
+	}
+
+	return nil
+}
+
+// now returns the current time using the configured clock.
+func (v *Validator) now() time.Time {
+	if v.Clock == nil {
+		return systemClock{}.Now()
+	}
+
+	return v.Clock.Now()
+}
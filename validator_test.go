@@ -0,0 +1,178 @@
+package jwt
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+var ValidatorTime = time.Unix(1424776307, 0).UTC()
+
+func fixedClock(t time.Time) Clock {
+	return ClockFunc(func() time.Time {
+		return t
+	})
+}
+
+func newValidatorToken() *Token {
+	tkn := NewToken()
+	tkn.Issuer = "MyIssuer"
+	tkn.Subject = "MySubject"
+	tkn.Audience = Audience{"MyAudience"}
+	tkn.IssuedAt = ValidatorTime
+	tkn.NotBefore = ValidatorTime
+	tkn.Expires = ValidatorTime.Add(1 * time.Hour)
+	return tkn
+}
+
+func TestValidatorValidate(t *testing.T) {
+	v := &Validator{
+		Issuer:   "MyIssuer",
+		Subject:  "MySubject",
+		Audience: []string{"MyAudience"},
+		Clock:    fixedClock(ValidatorTime.Add(1 * time.Minute)),
+	}
+	if err := v.Validate(newValidatorToken()); err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+}
+
+func TestValidatorValidate_InvalidIssuer(t *testing.T) {
+	v := &Validator{Issuer: "TestIssuer", Clock: fixedClock(ValidatorTime.Add(1 * time.Minute))}
+	if err := v.Validate(newValidatorToken()); !errors.Is(err, ErrInvalidIssuer) {
+		t.Fatalf("expected %#q, got %#q", ErrInvalidIssuer, err)
+	}
+}
+
+func TestValidatorValidate_InvalidSubject(t *testing.T) {
+	v := &Validator{Subject: "TestSubject", Clock: fixedClock(ValidatorTime.Add(1 * time.Minute))}
+	if err := v.Validate(newValidatorToken()); !errors.Is(err, ErrInvalidSubject) {
+		t.Fatalf("expected %#q, got %#q", ErrInvalidSubject, err)
+	}
+}
+
+func TestValidatorValidate_InvalidAudience(t *testing.T) {
+	v := &Validator{Audience: []string{"TestAudience"}, Clock: fixedClock(ValidatorTime.Add(1 * time.Minute))}
+	if err := v.Validate(newValidatorToken()); !errors.Is(err, ErrInvalidAudience) {
+		t.Fatalf("expected %#q, got %#q", ErrInvalidAudience, err)
+	}
+}
+
+func TestValidatorValidate_NotValidYet(t *testing.T) {
+	tkn := newValidatorToken()
+	tkn.NotBefore = ValidatorTime.Add(30 * time.Second)
+	v := &Validator{Clock: fixedClock(ValidatorTime.Add(10 * time.Second))}
+	if err := v.Validate(tkn); !errors.Is(err, ErrTokenNotValidYet) {
+		t.Fatalf("expected %#q, got %#q", ErrTokenNotValidYet, err)
+	}
+	v.Leeway = 30 * time.Second
+	if err := v.Validate(tkn); err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+}
+
+func TestValidatorValidate_Expired(t *testing.T) {
+	tkn := newValidatorToken()
+	v := &Validator{Clock: fixedClock(tkn.Expires.Add(10 * time.Second))}
+	if err := v.Validate(tkn); !errors.Is(err, ErrTokenExpired) {
+		t.Fatalf("expected %#q, got %#q", ErrTokenExpired, err)
+	}
+	v.Leeway = 30 * time.Second
+	if err := v.Validate(tkn); err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+}
+
+func TestValidatorValidate_UsedBeforeIssued(t *testing.T) {
+	tkn := newValidatorToken()
+	tkn.NotBefore = ValidatorTime.Add(-1 * time.Hour)
+	v := &Validator{Clock: fixedClock(ValidatorTime.Add(-5 * time.Second))}
+	if err := v.Validate(tkn); !errors.Is(err, ErrTokenUsedBeforeIssued) {
+		t.Fatalf("expected %#q, got %#q", ErrTokenUsedBeforeIssued, err)
+	}
+	v.Leeway = 5 * time.Second
+	if err := v.Validate(tkn); err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+}
+
+func TestValidatorValidate_NoExpiry(t *testing.T) {
+	tkn := newValidatorToken()
+	tkn.Expires = tkn.IssuedAt
+	v := &Validator{Clock: fixedClock(ValidatorTime.Add(24 * time.Hour))}
+	if err := v.Validate(tkn); err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+}
+
+func TestValidatorValidate_SystemClock(t *testing.T) {
+	v := &Validator{}
+	if err := v.Validate(NewToken()); err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+}
+
+func TestValidatorValidate_Replayed(t *testing.T) {
+	tkn := newValidatorToken()
+	tkn.ID = "MyID"
+	cache := NewMemoryReplayCache(0)
+	cache.Clock = fixedClock(ValidatorTime.Add(1 * time.Minute))
+	v := &Validator{
+		Clock:       cache.Clock,
+		ReplayCache: cache,
+	}
+	if err := v.Validate(tkn); err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+	if err := v.Validate(tkn); !errors.Is(err, ErrTokenReplayed) {
+		t.Fatalf("expected %#q, got %#q", ErrTokenReplayed, err)
+	}
+}
+
+func TestValidatorValidate_MissingTokenID(t *testing.T) {
+	v := &Validator{
+		Clock:       fixedClock(ValidatorTime.Add(1 * time.Minute)),
+		ReplayCache: NewMemoryReplayCache(0),
+	}
+	if err := v.Validate(newValidatorToken()); !errors.Is(err, ErrMissingTokenID) {
+		t.Fatalf("expected %#q, got %#q", ErrMissingTokenID, err)
+	}
+}
+
+func TestValidatorValidate_ReplayExpiredNotRecorded(t *testing.T) {
+	tkn := newValidatorToken()
+	tkn.ID = "MyID"
+	cache := NewMemoryReplayCache(0)
+	v := &Validator{
+		Clock:       fixedClock(tkn.Expires.Add(1 * time.Minute)),
+		ReplayCache: cache,
+	}
+	if err := v.Validate(tkn); !errors.Is(err, ErrTokenExpired) {
+		t.Fatalf("expected %#q, got %#q", ErrTokenExpired, err)
+	}
+	if _, ok := cache.ids["MyID"]; ok {
+		t.Fatalf("expected %#q not to be recorded", "MyID")
+	}
+}
+
+func TestValidatorValidate_MultipleErrors(t *testing.T) {
+	tkn := newValidatorToken()
+	v := &Validator{
+		Issuer:  "TestIssuer",
+		Subject: "TestSubject",
+		Clock:   fixedClock(tkn.Expires.Add(1 * time.Minute)),
+	}
+	err := v.Validate(tkn)
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected %T, got %#q", validationErr, err)
+	}
+	if len(validationErr.Errors) != 3 {
+		t.Fatalf("expected %d, got %d", 3, len(validationErr.Errors))
+	}
+	for _, expected := range []error{ErrInvalidIssuer, ErrInvalidSubject, ErrTokenExpired, ErrTokenClaimsInvalid} {
+		if !errors.Is(err, expected) {
+			t.Fatalf("expected %#q, got %#q", expected, err)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package jwt
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+)
+
+// CertificateChain returns the certificates in the "x5c" header parameter,
+// starting with the certificate containing the signing key. It returns nil if
+// the parameter is not set, and an error if it is not a non-empty array of
+// base64 encoded DER certificates.
+func (h Header) CertificateChain() ([]*x509.Certificate, error) {
+	v, ok := h["x5c"]
+	if !ok {
+		return nil, nil
+	}
+
+	values, ok := v.([]interface{})
+	if !ok || len(values) == 0 {
+		return nil, ErrInvalidToken
+	}
+
+	chain := make([]*x509.Certificate, 0, len(values))
+	for _, s := range values {
+		if _, ok := s.(string); !ok {
+			return nil, ErrInvalidToken
+		}
+
+		// Unlike the rest of the token, the certificates use the standard
+		// base64 encoding with padding.
+		b, err := base64.StdEncoding.DecodeString(s.(string))
+		if err != nil {
+			return nil, ErrInvalidToken
+		}
+
+		c, err := x509.ParseCertificate(b)
+		if err != nil {
+			return nil, ErrInvalidToken
+		}
+		chain = append(chain, c)
+	}
+
+	return chain, nil
+}
+
+// CertificateChainFunc returns a key function that verifies the certificate
+// chain in the "x5c" header of the token, and selects the public key of the
+// first certificate as the verification key.
+//
+// The chain is verified using the given options. Roots must be set to the
+// trusted certificate authorities, since the system roots would accept any
+// publicly trusted certificate; decoding fails with ErrNoCertificateRoots if
+// it is nil. Optionally set DNSName and KeyUsages to constrain
+// the certificate. The other certificates of the chain are added to the
+// Intermediates. Note that x509 requires the server authentication extended
+// key usage if KeyUsages is empty, so use x509.ExtKeyUsageAny to accept any
+// usage. The validity period of the certificates is checked against
+// CurrentTime, or the current time if it is not set.
+//
+// Decoding fails with ErrNoKeyProvided if the token has no "x5c" header and
+// with ErrInvalidCertificateChain if the chain cannot be verified. Use
+// Parser.Algorithms to restrict the algorithms that are accepted.
+func CertificateChainFunc(opts x509.VerifyOptions) KeyFunc {
+	return func(h Header) (Algorithm, interface{}, error) {
+		if opts.Roots == nil {
+			return "", nil, ErrNoCertificateRoots
+		}
+
+		chain, err := h.CertificateChain()
+		if err != nil {
+			return "", nil, err
+		}
+		if len(chain) == 0 {
+			return "", nil, ErrNoKeyProvided
+		}
+
+		intermediates := x509.NewCertPool()
+		if opts.Intermediates != nil {
+			intermediates = opts.Intermediates.Clone()
+		}
+		for _, c := range chain[1:] {
+			intermediates.AddCert(c)
+		}
+
+		o := opts
+		o.Intermediates = intermediates
+		if _, err := chain[0].Verify(o); err != nil {
+			return "", nil, fmt.Errorf("%w: %w", ErrInvalidCertificateChain, err)
+		}
+
+		return h.Algorithm(), chain[0].PublicKey, nil
+	}
+}
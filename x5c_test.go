@@ -0,0 +1,190 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+)
+
+// testCertificate creates a certificate signed by the parent, or a self-signed
+// certificate authority if the parent is nil.
+func testCertificate(t *testing.T, template *x509.Certificate, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template.NotBefore = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	template.NotAfter = time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage = x509.KeyUsageCertSign
+		parent, parentKey = template, key
+	}
+
+	b, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := x509.ParseCertificate(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return c, key
+}
+
+// testChain creates a root, an intermediate and a leaf certificate, and
+// returns a token signed by the leaf along with the pool containing the root.
+func testChain(t *testing.T) (string, *x509.CertPool) {
+	root, rootKey := testCertificate(t, &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "Root"},
+	}, nil, nil)
+
+	intermediate, intermediateKey := testCertificate(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(2),
+		Subject:               pkix.Name{CommonName: "Intermediate"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, root, rootKey)
+
+	leaf, leafKey := testCertificate(t, &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "partner.example.com"},
+		DNSNames:     []string{"partner.example.com"},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, intermediate, intermediateKey)
+
+	tkn := NewToken()
+	tkn.Algorithm = ES256
+	tkn.Issuer = "MyIssuer"
+	tkn.Headers["x5c"] = []string{
+		base64.StdEncoding.EncodeToString(leaf.Raw),
+		base64.StdEncoding.EncodeToString(intermediate.Raw),
+	}
+	str, err := tkn.Sign(leafKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(root)
+
+	return str, roots
+}
+
+func testVerifyOptions(roots *x509.CertPool) x509.VerifyOptions {
+	return x509.VerifyOptions{
+		Roots:       roots,
+		DNSName:     "partner.example.com",
+		KeyUsages:   []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		CurrentTime: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestCertificateChainFunc(t *testing.T) {
+	str, roots := testChain(t)
+
+	tkn, err := NewParser(CertificateChainFunc(testVerifyOptions(roots))).Decode(str)
+	if err != nil {
+		t.Fatalf("expected nil, got %#q", err)
+	}
+	if tkn.Issuer != "MyIssuer" {
+		t.Fatalf("expected %#q, got %#q", "MyIssuer", tkn.Issuer)
+	}
+}
+
+func TestCertificateChainFunc_UntrustedRoot(t *testing.T) {
+	str, _ := testChain(t)
+	_, roots := testChain(t)
+
+	_, err := NewParser(CertificateChainFunc(testVerifyOptions(roots))).Decode(str)
+	if !errors.Is(err, ErrInvalidCertificateChain) {
+		t.Fatalf("expected %#q, got %#q", ErrInvalidCertificateChain, err)
+	}
+	var unknown x509.UnknownAuthorityError
+	if !errors.As(err, &unknown) {
+		t.Fatalf("expected %T, got %#q", unknown, err)
+	}
+}
+
+func TestCertificateChainFunc_NoRoots(t *testing.T) {
+	str, _ := testChain(t)
+
+	opts := testVerifyOptions(nil)
+	_, err := NewParser(CertificateChainFunc(opts)).Decode(str)
+	if !errors.Is(err, ErrNoCertificateRoots) {
+		t.Fatalf("expected %#q, got %#q", ErrNoCertificateRoots, err)
+	}
+	if !errors.Is(err, ErrTokenSignatureInvalid) {
+		t.Fatalf("expected %#q, got %#q", ErrTokenSignatureInvalid, err)
+	}
+}
+
+func TestCertificateChainFunc_Constraints(t *testing.T) {
+	str, roots := testChain(t)
+
+	opts := testVerifyOptions(roots)
+	opts.DNSName = "other.example.com"
+	if _, err := NewParser(CertificateChainFunc(opts)).Decode(str); !errors.Is(err, ErrInvalidCertificateChain) {
+		t.Fatalf("expected %#q, got %#q", ErrInvalidCertificateChain, err)
+	}
+
+	opts = testVerifyOptions(roots)
+	opts.KeyUsages = []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning}
+	if _, err := NewParser(CertificateChainFunc(opts)).Decode(str); !errors.Is(err, ErrInvalidCertificateChain) {
+		t.Fatalf("expected %#q, got %#q", ErrInvalidCertificateChain, err)
+	}
+}
+
+func TestCertificateChainFunc_Expired(t *testing.T) {
+	str, roots := testChain(t)
+
+	opts := testVerifyOptions(roots)
+	opts.CurrentTime = time.Date(2031, 1, 1, 0, 0, 0, 0, time.UTC)
+	if _, err := NewParser(CertificateChainFunc(opts)).Decode(str); !errors.Is(err, ErrInvalidCertificateChain) {
+		t.Fatalf("expected %#q, got %#q", ErrInvalidCertificateChain, err)
+	}
+}
+
+func TestCertificateChainFunc_AlgorithmNotAllowed(t *testing.T) {
+	str, roots := testChain(t)
+
+	p := NewParser(CertificateChainFunc(testVerifyOptions(roots)))
+	p.Algorithms = []Algorithm{RS256}
+	if _, err := p.Decode(str); !errors.Is(err, ErrAlgorithmNotAllowed) {
+		t.Fatalf("expected %#q, got %#q", ErrAlgorithmNotAllowed, err)
+	}
+}
+
+func TestCertificateChainFunc_NoChain(t *testing.T) {
+	str, err := NewToken().Sign("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = NewParser(CertificateChainFunc(x509.VerifyOptions{Roots: x509.NewCertPool()})).Decode(str)
+	if !errors.Is(err, ErrNoKeyProvided) {
+		t.Fatalf("expected %#q, got %#q", ErrNoKeyProvided, err)
+	}
+}
+
+func TestHeaderCertificateChain_Invalid(t *testing.T) {
+	for _, v := range []interface{}{"MII", []interface{}{}, []interface{}{1}, []interface{}{"!"}, []interface{}{"AAAA"}} {
+		if _, err := (Header{"x5c": v}).CertificateChain(); err != ErrInvalidToken {
+			t.Fatalf("expected %#q, got %#q", ErrInvalidToken, err)
+		}
+	}
+}